@@ -3,15 +3,21 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"text/template"
+
+	"github.com/bnema/purego-webp/internal/dynlib"
 )
 
 type spec struct {
@@ -23,6 +29,16 @@ type specFunction struct {
 	Signature string `json:"signature"`
 	Symbol    string `json:"symbol,omitempty"`
 	Optional  bool   `json:"optional,omitempty"`
+	// Library names the companion library exporting the symbol ("demux",
+	// "mux"). Empty means the core libwebp.
+	Library string `json:"library,omitempty"`
+	// Doc documents the generated function. It is emitted after the
+	// function name, so it reads "returns ...", "decodes ...".
+	Doc string `json:"doc,omitempty"`
+	// GOOS restricts the function to the listed operating systems. Such
+	// functions are generated into a separate file with a //go:build
+	// constraint; empty means every platform.
+	GOOS []string `json:"goos,omitempty"`
 }
 
 type tmplFunction struct {
@@ -34,13 +50,40 @@ type tmplFunction struct {
 	Args      string
 	HasReturn bool
 	Optional  bool
+	// Ensure is the loader function that loads the symbol's library, used
+	// by the availability helpers of optional functions.
+	Ensure string
+	// Doc holds the doc comment lines, without the "// " prefix.
+	Doc []string
 }
 
-type tmplData struct {
+// tmplRegistration is one generated register function covering the symbols
+// of a single shared library.
+type tmplRegistration struct {
+	Func      string
+	Library   string
 	Functions []tmplFunction
 }
 
+type tmplData struct {
+	// BuildTag is the //go:build expression of a per-platform file; empty
+	// for the main file.
+	BuildTag      string
+	Functions     []tmplFunction
+	Registrations []tmplRegistration
+	// PlatformHooks makes the main file's register functions also run the
+	// ones of the per-platform files.
+	PlatformHooks bool
+}
+
+// mainFileName is the generated file holding the functions without a GOOS
+// constraint.
+const mainFileName = "generated_symbols.go"
+
 func main() {
+	verify := flag.Bool("verify", false, "after generating, check that every non-optional symbol resolves in the installed libraries")
+	flag.Parse()
+
 	root, err := os.Getwd()
 	if err != nil {
 		panic(err)
@@ -48,38 +91,196 @@ func main() {
 
 	specPath := filepath.Join(root, "gen", "spec.json")
 	templatePath := filepath.Join(root, "templates", "internal_generated_symbols.go.tmpl")
-	outputPath := filepath.Join(root, "internal", "libwebp", "generated_symbols.go")
+	outputDir := filepath.Join(root, "internal", "libwebp")
 
 	sp, err := readSpec(specPath)
 	if err != nil {
 		panic(err)
 	}
 
-	data, err := buildTemplateData(sp)
+	files, err := generateFiles(sp, templatePath)
 	if err != nil {
 		panic(err)
 	}
 
-	tmpl, err := template.ParseFiles(templatePath)
+	// Drop per-platform files whose functions left the spec.
+	stale, err := filepath.Glob(filepath.Join(outputDir, "generated_symbols_*.go"))
 	if err != nil {
 		panic(err)
 	}
+	for _, path := range stale {
+		if _, ok := files[filepath.Base(path)]; !ok {
+			if err := os.Remove(path); err != nil {
+				panic(err)
+			}
+		}
+	}
 
-	var out bytes.Buffer
-	if err := tmpl.Execute(&out, data); err != nil {
-		panic(err)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		outputPath := filepath.Join(outputDir, name)
+		if err := os.WriteFile(outputPath, files[name], 0o644); err != nil {
+			panic(err)
+		}
+		fmt.Println("generated", outputPath)
 	}
 
-	fmted, err := format.Source(out.Bytes())
+	if *verify {
+		missing := verifySymbols(sp, dlsymLookup())
+		for _, m := range missing {
+			fmt.Fprintln(os.Stderr, "missing:", m)
+		}
+		if len(missing) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println("verified", len(sp.Functions), "symbols")
+	}
+}
+
+// symbolLookup resolves symbol in the shared library named by a spec
+// "library" value.
+type symbolLookup func(library, symbol string) error
+
+// verifySymbols returns a description of every non-optional spec symbol that
+// lookup cannot resolve. Functions restricted to other platforms are skipped.
+func verifySymbols(s *spec, lookup symbolLookup) []string {
+	var missing []string
+	for _, f := range s.Functions {
+		if f.Optional || (len(f.GOOS) > 0 && !slices.Contains(f.GOOS, runtime.GOOS)) {
+			continue
+		}
+		if err := lookup(f.Library, symbolName(f)); err != nil {
+			missing = append(missing, fmt.Sprintf("%s: %v", f.Name, err))
+		}
+	}
+	return missing
+}
+
+// dlsymLookup resolves symbols in the libraries the runtime loader would
+// open, opening each library once.
+func dlsymLookup() symbolLookup {
+	type handle struct {
+		lib uintptr
+		err error
+	}
+	handles := map[string]handle{}
+	return func(library, symbol string) error {
+		h, ok := handles[library]
+		if !ok {
+			h.lib, h.err = dynlib.Open(library)
+			handles[library] = h
+		}
+		if h.err != nil {
+			return fmt.Errorf("open library %q: %w", library, h.err)
+		}
+		_, err := dynlib.Dlsym(h.lib, symbol)
+		return err
+	}
+}
+
+// generate renders and formats the main bindings file for sp with the
+// template at templatePath.
+func generate(sp *spec, templatePath string) ([]byte, error) {
+	files, err := generateFiles(sp, templatePath)
 	if err != nil {
-		panic(fmt.Errorf("format generated source: %w\n\n%s", err, out.String()))
+		return nil, err
 	}
+	return files[mainFileName], nil
+}
 
-	if err := os.WriteFile(outputPath, fmted, 0o644); err != nil {
-		panic(err)
+// generateFiles renders and formats the bindings for sp, keyed by file name:
+// mainFileName for the functions available everywhere and one
+// generated_symbols_<goos>.go file per distinct GOOS constraint.
+func generateFiles(sp *spec, templatePath string) (map[string][]byte, error) {
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Partition by constraint, keeping spec order within each file.
+	var common []specFunction
+	platform := map[string][]specFunction{}
+	var keys []string
+	libraries := map[string]bool{}
+	for _, f := range sp.Functions {
+		libraries[f.Library] = true
+		if len(f.GOOS) == 0 {
+			common = append(common, f)
+			continue
+		}
+		key := strings.Join(slices.Sorted(slices.Values(f.GOOS)), "_")
+		if _, ok := platform[key]; !ok {
+			keys = append(keys, key)
+		}
+		platform[key] = append(platform[key], f)
 	}
 
-	fmt.Println("generated", outputPath)
+	data, err := buildTemplateData(common, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		data.PlatformHooks = true
+		// Every library needs a main register function to run its
+		// platform ones, even without common functions.
+		for _, reg := range data.Registrations {
+			delete(libraries, reg.Library)
+		}
+		for _, lib := range slices.Sorted(maps.Keys(libraries)) {
+			data.Registrations = append(data.Registrations, tmplRegistration{Func: registerFuncName(lib), Library: lib})
+		}
+	}
+
+	files := map[string][]byte{}
+	if files[mainFileName], err = render(tmpl, data); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		goos := strings.Split(key, "_")
+		data, err := buildTemplateData(platform[key], platformSuffix(goos))
+		if err != nil {
+			return nil, err
+		}
+		data.BuildTag = strings.Join(goos, " || ")
+		if files[platformFileName(goos)], err = render(tmpl, data); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// platformFileName returns the generated file for functions restricted to
+// goos. A single OS uses the _<goos>.go suffix; a list gets a neutral final
+// element so the file name does not imply a narrower constraint than its
+// //go:build line.
+func platformFileName(goos []string) string {
+	name := "generated_symbols_" + strings.Join(goos, "_")
+	if len(goos) > 1 {
+		name += "_gen"
+	}
+	return name + ".go"
+}
+
+// platformSuffix returns the register function suffix for goos, such as
+// "Linux" or "DarwinLinux".
+func platformSuffix(goos []string) string {
+	var b strings.Builder
+	for _, g := range goos {
+		b.WriteString(strings.ToUpper(g[:1]) + g[1:])
+	}
+	return b.String()
+}
+
+func render(tmpl *template.Template, data *tmplData) ([]byte, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+
+	fmted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w\n\n%s", err, out.String())
+	}
+	return fmted, nil
 }
 
 func readSpec(path string) (*spec, error) {
@@ -96,17 +297,48 @@ func readSpec(path string) (*spec, error) {
 	return &s, nil
 }
 
-func buildTemplateData(s *spec) (*tmplData, error) {
-	funcs := make([]tmplFunction, 0, len(s.Functions))
-	for _, f := range s.Functions {
+// buildTemplateData prepares fns for the template. suffix is appended to
+// the register function names of per-platform files.
+func buildTemplateData(fns []specFunction, suffix string) (*tmplData, error) {
+	funcs := make([]tmplFunction, 0, len(fns))
+	var regs []tmplRegistration
+	regIndex := map[string]int{}
+	for _, f := range fns {
 		tf, err := parseFunction(f)
 		if err != nil {
 			return nil, err
 		}
 		funcs = append(funcs, tf)
+
+		name := registerFuncName(f.Library) + suffix
+		i, ok := regIndex[name]
+		if !ok {
+			i = len(regs)
+			regIndex[name] = i
+			regs = append(regs, tmplRegistration{Func: name, Library: f.Library})
+		}
+		regs[i].Functions = append(regs[i].Functions, tf)
+	}
+
+	return &tmplData{Functions: funcs, Registrations: regs}, nil
+}
+
+// ensureFuncName returns the loader function for library: EnsureLoaded for
+// the core libwebp, EnsureDemuxLoaded for "demux", and so on.
+func ensureFuncName(library string) string {
+	if library == "" {
+		return "EnsureLoaded"
 	}
+	return "Ensure" + strings.ToUpper(library[:1]) + library[1:] + "Loaded"
+}
 
-	return &tmplData{Functions: funcs}, nil
+// registerFuncName returns the generated register function for library:
+// registerAll for the core libwebp, registerAllDemux for "demux", and so on.
+func registerFuncName(library string) string {
+	if library == "" {
+		return "registerAll"
+	}
+	return "registerAll" + strings.ToUpper(library[:1]) + library[1:]
 }
 
 func parseFunction(sf specFunction) (tmplFunction, error) {
@@ -124,18 +356,52 @@ func parseFunction(sf specFunction) (tmplFunction, error) {
 	params, args := buildParamsAndArgs(ft.Params)
 	results, hasReturn := buildResults(ft.Results)
 
+	signature := sf.Signature
+	if bridgeCallbackParams(ft) {
+		signature = typesExprString(ft)
+	}
+
 	return tmplFunction{
 		Name:      sf.Name,
-		Signature: sf.Signature,
+		Signature: signature,
 		Symbol:    symbolName(sf),
 		Params:    params,
 		Results:   results,
 		Args:      args,
 		HasReturn: hasReturn,
 		Optional:  sf.Optional,
+		Ensure:    ensureFuncName(sf.Library),
+		Doc:       docLines(sf),
 	}, nil
 }
 
+// docLines returns the doc comment of sf split into lines, the first one
+// starting with the function name. It returns nil when sf has no doc.
+func docLines(sf specFunction) []string {
+	doc := strings.TrimSpace(sf.Doc)
+	if doc == "" {
+		return nil
+	}
+	return strings.Split(sf.Name+" "+doc, "\n")
+}
+
+// bridgeCallbackParams replaces every func-typed parameter of ft with
+// uintptr, which is how purego passes C callbacks (see purego.NewCallback),
+// and reports whether any was replaced.
+func bridgeCallbackParams(ft *ast.FuncType) bool {
+	if ft.Params == nil {
+		return false
+	}
+	bridged := false
+	for _, field := range ft.Params.List {
+		if _, ok := field.Type.(*ast.FuncType); ok {
+			field.Type = ast.NewIdent("uintptr")
+			bridged = true
+		}
+	}
+	return bridged
+}
+
 func symbolName(sf specFunction) string {
 	if sf.Symbol != "" {
 		return sf.Symbol
@@ -199,7 +465,12 @@ func buildResults(fields *ast.FieldList) (string, bool) {
 	return " (" + strings.Join(parts, ", ") + ")", true
 }
 
+// exprString returns the Go type used in the binding for expr. Callback
+// (func) types are bridged to uintptr.
 func exprString(expr ast.Expr) string {
+	if _, ok := expr.(*ast.FuncType); ok {
+		return "uintptr"
+	}
 	return strings.TrimSpace(typesExprString(expr))
 }
 
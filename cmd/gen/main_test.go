@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var templatePath = filepath.Join("..", "..", "templates", "internal_generated_symbols.go.tmpl")
+
+func TestGenerateAvailabilityHelpers(t *testing.T) {
+	sp := &spec{Functions: []specFunction{
+		{Name: "WebPRequired", Signature: "func(x int32) int32"},
+		{Name: "WebPOptional", Signature: "func(p *byte)", Optional: true, Library: "demux"},
+	}}
+
+	out, err := generate(sp, templatePath)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	src := string(out)
+
+	for _, want := range []string{
+		"func WebPOptionalAvailable() bool {",
+		"return EnsureDemuxLoaded() == nil && xWebPOptional != nil",
+		`registerOptional(lib, &xWebPOptional, "WebPOptional")`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated source missing %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "WebPRequiredAvailable") {
+		t.Errorf("generated an availability helper for a required function:\n%s", src)
+	}
+}
+
+func TestGenerateCallbackParams(t *testing.T) {
+	sp := &spec{Functions: []specFunction{
+		{Name: "WebPSetHook", Signature: "func(config *byte, hook func(percent int32, picture *byte) int32, user uintptr) int32"},
+		{Name: "WebPPlain", Signature: "func(x int32) int32"},
+	}}
+
+	out, err := generate(sp, templatePath)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	src := string(out)
+
+	for _, want := range []string{
+		"xWebPSetHook func(config *byte, hook uintptr, user uintptr) int32",
+		"func WebPSetHook(config *byte, hook uintptr, user uintptr) int32 {",
+		"return xWebPSetHook(config, hook, user)",
+		"func WebPPlain(x int32) int32 {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated source missing %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "func(percent") {
+		t.Errorf("generated source kept a callback type:\n%s", src)
+	}
+}
+
+func TestGenerateDocComments(t *testing.T) {
+	sp := &spec{Functions: []specFunction{
+		{Name: "WebPDocumented", Signature: "func() int32", Doc: "returns the answer.\nIt never fails."},
+		{Name: "WebPBare", Signature: "func() int32"},
+	}}
+
+	out, err := generate(sp, templatePath)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	src := string(out)
+
+	want := "// WebPDocumented returns the answer.\n// It never fails.\nfunc WebPDocumented() int32 {"
+	if !strings.Contains(src, want) {
+		t.Errorf("generated source missing %q:\n%s", want, src)
+	}
+	if strings.Contains(src, "// WebPBare") {
+		t.Errorf("generated a doc comment for an undocumented function:\n%s", src)
+	}
+}
+
+func TestGeneratePlatformFiles(t *testing.T) {
+	sp := &spec{Functions: []specFunction{
+		{Name: "WebPEverywhere", Signature: "func() int32"},
+		{Name: "WebPLinuxOnly", Signature: "func() int32", GOOS: []string{"linux"}},
+		{Name: "WebPMuxUnix", Signature: "func() int32", Library: "mux", GOOS: []string{"linux", "darwin"}},
+	}}
+
+	files, err := generateFiles(sp, templatePath)
+	if err != nil {
+		t.Fatalf("generateFiles() error = %v", err)
+	}
+	names := slices.Sorted(maps.Keys(files))
+	wantNames := []string{"generated_symbols.go", "generated_symbols_darwin_linux_gen.go", "generated_symbols_linux.go"}
+	if !slices.Equal(names, wantNames) {
+		t.Fatalf("generated files = %q, want %q", names, wantNames)
+	}
+
+	main := string(files["generated_symbols.go"])
+	if strings.Contains(main, "WebPLinuxOnly") || strings.Contains(main, "go:build") {
+		t.Errorf("main file has platform content:\n%s", main)
+	}
+	for _, want := range []string{
+		"func WebPEverywhere() int32 {",
+		`range platformRegisters[""]`,
+		// mux has no common functions but must still run its platform ones.
+		"func registerAllMux(lib uintptr) error {",
+		`range platformRegisters["mux"]`,
+	} {
+		if !strings.Contains(main, want) {
+			t.Errorf("main file missing %q:\n%s", want, main)
+		}
+	}
+
+	linux := string(files["generated_symbols_linux.go"])
+	for _, want := range []string{
+		"//go:build linux\n",
+		"func WebPLinuxOnly() int32 {",
+		"func registerAllLinux(lib uintptr) error {",
+		`platformRegisters[""] = append(platformRegisters[""], registerAllLinux)`,
+	} {
+		if !strings.Contains(linux, want) {
+			t.Errorf("linux file missing %q:\n%s", want, linux)
+		}
+	}
+	if strings.Contains(linux, "WebPEverywhere") {
+		t.Errorf("linux file has common function:\n%s", linux)
+	}
+
+	unix := string(files["generated_symbols_darwin_linux_gen.go"])
+	for _, want := range []string{
+		"//go:build darwin || linux\n",
+		`platformRegisters["mux"] = append(platformRegisters["mux"], registerAllMuxDarwinLinux)`,
+	} {
+		if !strings.Contains(unix, want) {
+			t.Errorf("darwin/linux file missing %q:\n%s", want, unix)
+		}
+	}
+}
+
+func TestVerifySymbols(t *testing.T) {
+	sp := &spec{Functions: []specFunction{
+		{Name: "WebPPresent", Signature: "func()"},
+		{Name: "WebPRenamed", Signature: "func()", Symbol: "WebPTypo"},
+		{Name: "WebPOptional", Signature: "func()", Optional: true},
+		{Name: "WebPDemuxOnly", Signature: "func()", Library: "demux"},
+	}}
+	exported := map[string]bool{"WebPPresent": true, "WebPDemuxOnly": true}
+	lookup := func(library, symbol string) error {
+		if library == "demux" {
+			return errors.New("library not installed")
+		}
+		if !exported[symbol] {
+			return errors.New("undefined symbol")
+		}
+		return nil
+	}
+
+	got := verifySymbols(sp, lookup)
+	want := []string{
+		"WebPRenamed: undefined symbol",
+		"WebPDemuxOnly: library not installed",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("verifySymbols() = %q, want %q", got, want)
+	}
+}
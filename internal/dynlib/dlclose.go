@@ -0,0 +1,10 @@
+//go:build (darwin || freebsd || linux || netbsd) && !android
+
+package dynlib
+
+import "github.com/bnema/purego"
+
+// Dlclose releases a handle returned by Dlopen.
+func Dlclose(h uintptr) error {
+	return purego.Dlclose(h)
+}
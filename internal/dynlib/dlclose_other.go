@@ -0,0 +1,9 @@
+//go:build !((darwin || freebsd || linux || netbsd) && !android) && !windows
+
+package dynlib
+
+// Dlclose keeps the library mapped: Android's linker does not reliably
+// unload libraries, and purego has no dlclose elsewhere.
+func Dlclose(uintptr) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package dynlib
+
+import "github.com/bnema/purego"
+
+// Dlopen loads name with dlopen, binding every symbol immediately.
+func Dlopen(name string) (uintptr, error) {
+	return purego.Dlopen(name, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+}
+
+// Dlsym resolves symbol in a handle returned by Dlopen.
+func Dlsym(lib uintptr, symbol string) (uintptr, error) {
+	return purego.Dlsym(lib, symbol)
+}
+
+// SetDllDirectory does nothing: only Windows has a DLL directory.
+func SetDllDirectory(string) error {
+	return nil
+}
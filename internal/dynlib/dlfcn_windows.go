@@ -0,0 +1,39 @@
+//go:build windows
+
+package dynlib
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procSetDllDirectoryW = syscall.NewLazyDLL("kernel32.dll").NewProc("SetDllDirectoryW")
+
+// Dlopen loads name with LoadLibrary.
+func Dlopen(name string) (uintptr, error) {
+	h, err := syscall.LoadLibrary(name)
+	return uintptr(h), err
+}
+
+// Dlsym resolves symbol in a handle returned by Dlopen.
+func Dlsym(lib uintptr, symbol string) (uintptr, error) {
+	return syscall.GetProcAddress(syscall.Handle(lib), symbol)
+}
+
+// Dlclose releases a handle returned by Dlopen.
+func Dlclose(h uintptr) error {
+	return syscall.FreeLibrary(syscall.Handle(h))
+}
+
+// SetDllDirectory makes LoadLibrary search dir, so that the DLLs a library
+// depends on are found next to it too.
+func SetDllDirectory(dir string) error {
+	p, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+	if ok, _, err := procSetDllDirectoryW.Call(uintptr(unsafe.Pointer(p))); ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package dynlib opens the libwebp shared libraries and resolves their
+// symbols. It holds no generated code, so cmd/gen can use it to verify the
+// symbols it is about to generate bindings for.
+package dynlib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// LibraryPathEnv names the environment variable holding a libwebp path to try
+// before the built-in candidate names.
+const LibraryPathEnv = "WEBP_LIBRARY_PATH"
+
+// Open opens the shared library for a spec "library" value ("" for the core
+// libwebp, "demux", "mux") from its candidate names.
+func Open(library string) (uintptr, error) {
+	switch library {
+	case "":
+		return OpenFirst(WithLibraryPathEnv(LibNames()))
+	case "demux":
+		return OpenFirst(DemuxLibNames())
+	case "mux":
+		return OpenFirst(MuxLibNames())
+	default:
+		return 0, fmt.Errorf("unknown library %q", library)
+	}
+}
+
+// OpenFirst opens the first of names that loads, or reports why each failed.
+func OpenFirst(names []string) (uintptr, error) {
+	var errs []error
+	for _, name := range names {
+		lib, err := Dlopen(name)
+		if err == nil {
+			return lib, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	return 0, errors.Join(errs...)
+}
+
+// WithLibraryPathEnv returns names, preceded by $WEBP_LIBRARY_PATH when set.
+func WithLibraryPathEnv(names []string) []string {
+	if path := os.Getenv(LibraryPathEnv); path != "" {
+		names = append([]string{path}, names...)
+	}
+	return names
+}
+
+// LibNames returns the names the core libwebp library is searched under.
+func LibNames() []string {
+	switch runtime.GOOS {
+	case "linux":
+		return []string{"libwebp.so", "libwebp.so.8", "libwebp.so.7", "libwebp.so.6"}
+	case "darwin":
+		return darwinLibNames("libwebp.dylib")
+	case "windows":
+		return []string{"libwebp.dll", "webp.dll"}
+	default:
+		return []string{"libwebp.so"}
+	}
+}
+
+// DemuxLibNames returns the names libwebpdemux is searched under.
+func DemuxLibNames() []string {
+	switch runtime.GOOS {
+	case "linux":
+		return []string{"libwebpdemux.so", "libwebpdemux.so.2"}
+	case "darwin":
+		return darwinLibNames("libwebpdemux.dylib")
+	case "windows":
+		return []string{"libwebpdemux.dll", "webpdemux.dll"}
+	default:
+		return []string{"libwebpdemux.so"}
+	}
+}
+
+// MuxLibNames returns the names libwebpmux is searched under.
+func MuxLibNames() []string {
+	switch runtime.GOOS {
+	case "linux":
+		return []string{"libwebpmux.so", "libwebpmux.so.3"}
+	case "darwin":
+		return darwinLibNames("libwebpmux.dylib")
+	case "windows":
+		return []string{"libwebpmux.dll", "webpmux.dll"}
+	default:
+		return []string{"libwebpmux.so"}
+	}
+}
+
+// darwinLibPrefixes are the Homebrew library directories (Apple Silicon,
+// then Intel), which are not on the default dlopen search path.
+var darwinLibPrefixes = []string{"/opt/homebrew/lib", "/usr/local/lib"}
+
+// darwinLibNames returns name followed by its absolute Homebrew paths.
+func darwinLibNames(name string) []string {
+	names := []string{name}
+	for _, dir := range darwinLibPrefixes {
+		names = append(names, dir+"/"+name)
+	}
+	return names
+}
+
+// InstalledLibPath returns the path of a core libwebp installed in a system
+// or Homebrew library directory, or "" when none is found. Tests use it to
+// load the library by path.
+func InstalledLibPath() string {
+	patterns := []string{
+		"/usr/lib/*/libwebp.so.*",
+		"/usr/lib64/libwebp.so.*",
+		"/usr/lib/libwebp.so.*",
+		"/usr/local/lib/libwebp.so.*",
+	}
+	for _, dir := range darwinLibPrefixes {
+		patterns = append(patterns, dir+"/libwebp.dylib")
+	}
+	for _, pattern := range patterns {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	return ""
+}
@@ -3,80 +3,113 @@
 package libwebp
 
 var (
-	xWebPGetInfo                   func(data *byte, dataSize uintptr, width *int32, height *int32) int32
-	xWebPDecodeRGBA                func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
-	xWebPDecodeARGB                func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
-	xWebPDecodeBGRA                func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
-	xWebPDecodeRGB                 func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
-	xWebPDecodeBGR                 func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
-	xWebPDecodeRGBAInto            func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
-	xWebPDecodeARGBInto            func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
-	xWebPDecodeBGRAInto            func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
-	xWebPDecodeRGBInto             func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
-	xWebPDecodeBGRInto             func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
-	xWebPDecodeYUV                 func(data *byte, dataSize uintptr, width *int32, height *int32, u **byte, v **byte, stride *int32, uvStride *int32) *byte
-	xWebPDecodeYUVInto             func(data *byte, dataSize uintptr, luma *byte, lumaSize uintptr, lumaStride int32, u *byte, uSize uintptr, uStride int32, v *byte, vSize uintptr, vStride int32) *byte
-	xWebPGetFeaturesInternal       func(data *byte, dataSize uintptr, features *WebPBitstreamFeatures, abiVersion int32) VP8StatusCode
-	xWebPInitDecBufferInternal     func(buffer *WebPDecBuffer, abiVersion int32) int32
-	xWebPFreeDecBuffer             func(buffer *WebPDecBuffer)
-	xWebPInitDecoderConfigInternal func(config *WebPDecoderConfig, abiVersion int32) int32
-	xWebPValidateDecoderConfig     func(config *WebPDecoderConfig) int32
-	xWebPDecode                    func(data *byte, dataSize uintptr, config *WebPDecoderConfig) VP8StatusCode
-	xWebPINewDecoder               func(outputBuffer *WebPDecBuffer) uintptr
-	xWebPINewRGB                   func(csp int32, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) uintptr
-	xWebPINewYUVA                  func(luma *byte, lumaSize uintptr, lumaStride int32, u *byte, uSize uintptr, uStride int32, v *byte, vSize uintptr, vStride int32, a *byte, aSize uintptr, aStride int32) uintptr
-	xWebPINewYUV                   func(luma *byte, lumaSize uintptr, lumaStride int32, u *byte, uSize uintptr, uStride int32, v *byte, vSize uintptr, vStride int32) uintptr
-	xWebPIDelete                   func(idec uintptr)
-	xWebPIAppend                   func(idec uintptr, data *byte, dataSize uintptr) VP8StatusCode
-	xWebPIUpdate                   func(idec uintptr, data *byte, dataSize uintptr) VP8StatusCode
-	xWebPIDecGetRGB                func(idec uintptr, lastY *int32, width *int32, height *int32, stride *int32) *byte
-	xWebPIDecGetYUVA               func(idec uintptr, lastY *int32, u **byte, v **byte, a **byte, width *int32, height *int32, stride *int32, uvStride *int32, aStride *int32) *byte
-	xWebPIDecodedArea              func(idec uintptr, left *int32, top *int32, width *int32, height *int32) *WebPDecBuffer
-	xWebPIDecode                   func(data *byte, dataSize uintptr, config *WebPDecoderConfig) uintptr
-	xWebPEncodeRGBA                func(rgba *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
-	xWebPEncodeRGB                 func(rgb *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
-	xWebPEncodeBGR                 func(bgr *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
-	xWebPEncodeBGRA                func(bgra *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
-	xWebPEncodeLosslessRGBA        func(rgba *byte, width int32, height int32, stride int32, output **byte) uintptr
-	xWebPEncodeLosslessRGB         func(rgb *byte, width int32, height int32, stride int32, output **byte) uintptr
-	xWebPEncodeLosslessBGR         func(bgr *byte, width int32, height int32, stride int32, output **byte) uintptr
-	xWebPEncodeLosslessBGRA        func(bgra *byte, width int32, height int32, stride int32, output **byte) uintptr
-	xWebPConfigInitInternal        func(config *WebPConfig, preset int32, quality float32, abiVersion int32) int32
-	xWebPConfigLosslessPreset      func(config *WebPConfig, level int32) int32
-	xWebPValidateConfig            func(config *WebPConfig) int32
-	xWebPMemoryWriterInit          func(writer *WebPMemoryWriter)
-	xWebPMemoryWriterClear         func(writer *WebPMemoryWriter)
-	xWebPMemoryWrite               func(data *byte, dataSize uintptr, picture *WebPPicture) int32
-	xWebPPictureInitInternal       func(picture *WebPPicture, abiVersion int32) int32
-	xWebPPictureAlloc              func(picture *WebPPicture) int32
-	xWebPPictureFree               func(picture *WebPPicture)
-	xWebPPictureCopy               func(src *WebPPicture, dst *WebPPicture) int32
-	xWebPPictureCrop               func(picture *WebPPicture, left int32, top int32, width int32, height int32) int32
-	xWebPPictureView               func(src *WebPPicture, left int32, top int32, width int32, height int32, dst *WebPPicture) int32
-	xWebPPictureIsView             func(picture *WebPPicture) int32
-	xWebPPictureRescale            func(picture *WebPPicture, width int32, height int32) int32
-	xWebPPictureImportRGB          func(picture *WebPPicture, rgb *byte, rgbStride int32) int32
-	xWebPPictureImportRGBA         func(picture *WebPPicture, rgba *byte, rgbaStride int32) int32
-	xWebPPictureImportRGBX         func(picture *WebPPicture, rgbx *byte, rgbxStride int32) int32
-	xWebPPictureImportBGR          func(picture *WebPPicture, bgr *byte, bgrStride int32) int32
-	xWebPPictureImportBGRA         func(picture *WebPPicture, bgra *byte, bgraStride int32) int32
-	xWebPPictureImportBGRX         func(picture *WebPPicture, bgrx *byte, bgrxStride int32) int32
-	xWebPPictureARGBToYUVA         func(picture *WebPPicture, colorspace int32) int32
-	xWebPPictureARGBToYUVADithered func(picture *WebPPicture, colorspace int32, dithering float32) int32
-	xWebPPictureSharpARGBToYUVA    func(picture *WebPPicture) int32
-	xWebPPictureSmartARGBToYUVA    func(picture *WebPPicture) int32
-	xWebPPictureYUVAToARGB         func(picture *WebPPicture) int32
-	xWebPCleanupTransparentArea    func(picture *WebPPicture)
-	xWebPPictureHasTransparency    func(picture *WebPPicture) int32
-	xWebPBlendAlpha                func(picture *WebPPicture, backgroundRGB uint32)
-	xWebPPlaneDistortion           func(src *byte, srcStride uintptr, ref *byte, refStride uintptr, width int32, height int32, xStep uintptr, distType int32, distortion *float32, result *float32) int32
-	xWebPPictureDistortion         func(src *WebPPicture, ref *WebPPicture, metricType int32, result *float32) int32
-	xWebPEncode                    func(config *WebPConfig, picture *WebPPicture) int32
-	xWebPFree                      func(ptr uintptr)
-	xWebPGetDecoderVersion         func() int32
-	xWebPGetEncoderVersion         func() int32
+	xWebPGetInfo                        func(data *byte, dataSize uintptr, width *int32, height *int32) int32
+	xWebPDecodeRGBA                     func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
+	xWebPDecodeARGB                     func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
+	xWebPDecodeBGRA                     func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
+	xWebPDecodeRGB                      func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
+	xWebPDecodeBGR                      func(data *byte, dataSize uintptr, width *int32, height *int32) *byte
+	xWebPDecodeRGBAInto                 func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
+	xWebPDecodeARGBInto                 func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
+	xWebPDecodeBGRAInto                 func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
+	xWebPDecodeRGBInto                  func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
+	xWebPDecodeBGRInto                  func(data *byte, dataSize uintptr, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) *byte
+	xWebPDecodeYUV                      func(data *byte, dataSize uintptr, width *int32, height *int32, u **byte, v **byte, stride *int32, uvStride *int32) *byte
+	xWebPDecodeYUVInto                  func(data *byte, dataSize uintptr, luma *byte, lumaSize uintptr, lumaStride int32, u *byte, uSize uintptr, uStride int32, v *byte, vSize uintptr, vStride int32) *byte
+	xWebPGetFeaturesInternal            func(data *byte, dataSize uintptr, features *WebPBitstreamFeatures, abiVersion int32) VP8StatusCode
+	xWebPInitDecBufferInternal          func(buffer *WebPDecBuffer, abiVersion int32) int32
+	xWebPFreeDecBuffer                  func(buffer *WebPDecBuffer)
+	xWebPInitDecoderConfigInternal      func(config *WebPDecoderConfig, abiVersion int32) int32
+	xWebPValidateDecoderConfig          func(config *WebPDecoderConfig) int32
+	xWebPDecode                         func(data *byte, dataSize uintptr, config *WebPDecoderConfig) VP8StatusCode
+	xWebPINewDecoder                    func(outputBuffer *WebPDecBuffer) uintptr
+	xWebPINewRGB                        func(csp int32, outputBuffer *byte, outputBufferSize uintptr, outputStride int32) uintptr
+	xWebPINewYUVA                       func(luma *byte, lumaSize uintptr, lumaStride int32, u *byte, uSize uintptr, uStride int32, v *byte, vSize uintptr, vStride int32, a *byte, aSize uintptr, aStride int32) uintptr
+	xWebPINewYUV                        func(luma *byte, lumaSize uintptr, lumaStride int32, u *byte, uSize uintptr, uStride int32, v *byte, vSize uintptr, vStride int32) uintptr
+	xWebPIDelete                        func(idec uintptr)
+	xWebPIAppend                        func(idec uintptr, data *byte, dataSize uintptr) VP8StatusCode
+	xWebPIUpdate                        func(idec uintptr, data *byte, dataSize uintptr) VP8StatusCode
+	xWebPIDecGetRGB                     func(idec uintptr, lastY *int32, width *int32, height *int32, stride *int32) *byte
+	xWebPIDecGetYUVA                    func(idec uintptr, lastY *int32, u **byte, v **byte, a **byte, width *int32, height *int32, stride *int32, uvStride *int32, aStride *int32) *byte
+	xWebPIDecodedArea                   func(idec uintptr, left *int32, top *int32, width *int32, height *int32) *WebPDecBuffer
+	xWebPIDecode                        func(data *byte, dataSize uintptr, config *WebPDecoderConfig) uintptr
+	xWebPEncodeRGBA                     func(rgba *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
+	xWebPEncodeRGB                      func(rgb *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
+	xWebPEncodeBGR                      func(bgr *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
+	xWebPEncodeBGRA                     func(bgra *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
+	xWebPEncodeLosslessRGBA             func(rgba *byte, width int32, height int32, stride int32, output **byte) uintptr
+	xWebPEncodeLosslessRGB              func(rgb *byte, width int32, height int32, stride int32, output **byte) uintptr
+	xWebPEncodeLosslessBGR              func(bgr *byte, width int32, height int32, stride int32, output **byte) uintptr
+	xWebPEncodeLosslessBGRA             func(bgra *byte, width int32, height int32, stride int32, output **byte) uintptr
+	xWebPConfigInitInternal             func(config *WebPConfig, preset int32, quality float32, abiVersion int32) int32
+	xWebPConfigLosslessPreset           func(config *WebPConfig, level int32) int32
+	xWebPValidateConfig                 func(config *WebPConfig) int32
+	xWebPMemoryWriterInit               func(writer *WebPMemoryWriter)
+	xWebPMemoryWriterClear              func(writer *WebPMemoryWriter)
+	xWebPMemoryWrite                    func(data *byte, dataSize uintptr, picture *WebPPicture) int32
+	xWebPPictureInitInternal            func(picture *WebPPicture, abiVersion int32) int32
+	xWebPPictureAlloc                   func(picture *WebPPicture) int32
+	xWebPPictureFree                    func(picture *WebPPicture)
+	xWebPPictureCopy                    func(src *WebPPicture, dst *WebPPicture) int32
+	xWebPPictureCrop                    func(picture *WebPPicture, left int32, top int32, width int32, height int32) int32
+	xWebPPictureView                    func(src *WebPPicture, left int32, top int32, width int32, height int32, dst *WebPPicture) int32
+	xWebPPictureIsView                  func(picture *WebPPicture) int32
+	xWebPPictureRescale                 func(picture *WebPPicture, width int32, height int32) int32
+	xWebPPictureImportRGB               func(picture *WebPPicture, rgb *byte, rgbStride int32) int32
+	xWebPPictureImportRGBA              func(picture *WebPPicture, rgba *byte, rgbaStride int32) int32
+	xWebPPictureImportRGBX              func(picture *WebPPicture, rgbx *byte, rgbxStride int32) int32
+	xWebPPictureImportBGR               func(picture *WebPPicture, bgr *byte, bgrStride int32) int32
+	xWebPPictureImportBGRA              func(picture *WebPPicture, bgra *byte, bgraStride int32) int32
+	xWebPPictureImportBGRX              func(picture *WebPPicture, bgrx *byte, bgrxStride int32) int32
+	xWebPPictureARGBToYUVA              func(picture *WebPPicture, colorspace int32) int32
+	xWebPPictureARGBToYUVADithered      func(picture *WebPPicture, colorspace int32, dithering float32) int32
+	xWebPPictureSharpARGBToYUVA         func(picture *WebPPicture) int32
+	xWebPPictureSmartARGBToYUVA         func(picture *WebPPicture) int32
+	xWebPPictureYUVAToARGB              func(picture *WebPPicture) int32
+	xWebPCleanupTransparentArea         func(picture *WebPPicture)
+	xWebPPictureHasTransparency         func(picture *WebPPicture) int32
+	xWebPBlendAlpha                     func(picture *WebPPicture, backgroundRGB uint32)
+	xWebPPlaneDistortion                func(src *byte, srcStride uintptr, ref *byte, refStride uintptr, width int32, height int32, xStep uintptr, distType int32, distortion *float32, result *float32) int32
+	xWebPPictureDistortion              func(src *WebPPicture, ref *WebPPicture, metricType int32, result *float32) int32
+	xWebPEncode                         func(config *WebPConfig, picture *WebPPicture) int32
+	xWebPFree                           func(ptr uintptr)
+	xWebPGetDecoderVersion              func() int32
+	xWebPGetEncoderVersion              func() int32
+	xWebPAnimDecoderOptionsInitInternal func(options *WebPAnimDecoderOptions, abiVersion int32) int32
+	xWebPAnimDecoderNewInternal         func(webpData *WebPData, options *WebPAnimDecoderOptions, abiVersion int32) uintptr
+	xWebPAnimDecoderGetInfo             func(dec uintptr, info *WebPAnimInfo) int32
+	xWebPAnimDecoderGetNext             func(dec uintptr, buf **byte, timestamp *int32) int32
+	xWebPAnimDecoderHasMoreFrames       func(dec uintptr) int32
+	xWebPAnimDecoderReset               func(dec uintptr)
+	xWebPAnimDecoderDelete              func(dec uintptr)
+	xWebPDemuxInternal                  func(data *WebPData, allowPartial int32, state *int32, abiVersion int32) uintptr
+	xWebPDemuxDelete                    func(dmux uintptr)
+	xWebPDemuxGetI                      func(dmux uintptr, feature int32) uint32
+	xWebPDemuxGetFrame                  func(dmux uintptr, frameNumber int32, iter *WebPIterator) int32
+	xWebPDemuxNextFrame                 func(iter *WebPIterator) int32
+	xWebPDemuxPrevFrame                 func(iter *WebPIterator) int32
+	xWebPDemuxReleaseIterator           func(iter *WebPIterator)
+	xWebPDemuxGetChunk                  func(dmux uintptr, fourcc *byte, chunkNumber int32, iter *WebPChunkIterator) int32
+	xWebPDemuxNextChunk                 func(iter *WebPChunkIterator) int32
+	xWebPDemuxPrevChunk                 func(iter *WebPChunkIterator) int32
+	xWebPDemuxReleaseChunkIterator      func(iter *WebPChunkIterator)
+	xWebPAnimEncoderOptionsInitInternal func(options *WebPAnimEncoderOptions, abiVersion int32) int32
+	xWebPAnimEncoderNewInternal         func(width int32, height int32, options *WebPAnimEncoderOptions, abiVersion int32) uintptr
+	xWebPAnimEncoderAdd                 func(enc uintptr, frame *WebPPicture, timestamp int32, config *WebPConfig) int32
+	xWebPAnimEncoderAssemble            func(enc uintptr, webpData *WebPData) int32
+	xWebPAnimEncoderGetError            func(enc uintptr) uintptr
+	xWebPAnimEncoderDelete              func(enc uintptr)
+	xWebPMuxCreateInternal              func(bitstream *WebPData, copyData int32, abiVersion int32) uintptr
+	xWebPNewInternal                    func(abiVersion int32) uintptr
+	xWebPMuxSetImage                    func(mux uintptr, bitstream *WebPData, copyData int32) int32
+	xWebPMuxSetChunk                    func(mux uintptr, fourcc *byte, chunkData *WebPData, copyData int32) int32
+	xWebPMuxGetChunk                    func(mux uintptr, fourcc *byte, chunkData *WebPData) int32
+	xWebPMuxDeleteChunk                 func(mux uintptr, fourcc *byte) int32
+	xWebPMuxAssemble                    func(mux uintptr, assembledData *WebPData) int32
+	xWebPMuxDelete                      func(mux uintptr)
 )
 
+// WebPGetInfo reads the dimensions of a WebP bitstream. It returns 0 if the header is invalid.
 func WebPGetInfo(data *byte, dataSize uintptr, width *int32, height *int32) int32 {
 	return xWebPGetInfo(data, dataSize, width, height)
 }
@@ -131,6 +164,11 @@ func WebPInitDecoderConfigInternal(config *WebPDecoderConfig, abiVersion int32)
 func WebPValidateDecoderConfig(config *WebPDecoderConfig) int32 {
 	return xWebPValidateDecoderConfig(config)
 }
+
+// WebPValidateDecoderConfigAvailable reports whether WebPValidateDecoderConfig was found.
+func WebPValidateDecoderConfigAvailable() bool {
+	return EnsureLoaded() == nil && xWebPValidateDecoderConfig != nil
+}
 func WebPDecode(data *byte, dataSize uintptr, config *WebPDecoderConfig) VP8StatusCode {
 	return xWebPDecode(data, dataSize, config)
 }
@@ -284,15 +322,117 @@ func WebPPictureDistortion(src *WebPPicture, ref *WebPPicture, metricType int32,
 func WebPEncode(config *WebPConfig, picture *WebPPicture) int32 {
 	return xWebPEncode(config, picture)
 }
+
+// WebPFree releases memory returned by the libwebp decoding and encoding functions.
 func WebPFree(ptr uintptr) {
 	xWebPFree(ptr)
 }
+
+// WebPGetDecoderVersion returns the decoder version packed as 0xMMmmrr.
 func WebPGetDecoderVersion() int32 {
 	return xWebPGetDecoderVersion()
 }
+
+// WebPGetEncoderVersion returns the encoder version packed as 0xMMmmrr.
 func WebPGetEncoderVersion() int32 {
 	return xWebPGetEncoderVersion()
 }
+func WebPAnimDecoderOptionsInitInternal(options *WebPAnimDecoderOptions, abiVersion int32) int32 {
+	return xWebPAnimDecoderOptionsInitInternal(options, abiVersion)
+}
+func WebPAnimDecoderNewInternal(webpData *WebPData, options *WebPAnimDecoderOptions, abiVersion int32) uintptr {
+	return xWebPAnimDecoderNewInternal(webpData, options, abiVersion)
+}
+func WebPAnimDecoderGetInfo(dec uintptr, info *WebPAnimInfo) int32 {
+	return xWebPAnimDecoderGetInfo(dec, info)
+}
+func WebPAnimDecoderGetNext(dec uintptr, buf **byte, timestamp *int32) int32 {
+	return xWebPAnimDecoderGetNext(dec, buf, timestamp)
+}
+func WebPAnimDecoderHasMoreFrames(dec uintptr) int32 {
+	return xWebPAnimDecoderHasMoreFrames(dec)
+}
+func WebPAnimDecoderReset(dec uintptr) {
+	xWebPAnimDecoderReset(dec)
+}
+func WebPAnimDecoderDelete(dec uintptr) {
+	xWebPAnimDecoderDelete(dec)
+}
+func WebPDemuxInternal(data *WebPData, allowPartial int32, state *int32, abiVersion int32) uintptr {
+	return xWebPDemuxInternal(data, allowPartial, state, abiVersion)
+}
+func WebPDemuxDelete(dmux uintptr) {
+	xWebPDemuxDelete(dmux)
+}
+func WebPDemuxGetI(dmux uintptr, feature int32) uint32 {
+	return xWebPDemuxGetI(dmux, feature)
+}
+func WebPDemuxGetFrame(dmux uintptr, frameNumber int32, iter *WebPIterator) int32 {
+	return xWebPDemuxGetFrame(dmux, frameNumber, iter)
+}
+func WebPDemuxNextFrame(iter *WebPIterator) int32 {
+	return xWebPDemuxNextFrame(iter)
+}
+func WebPDemuxPrevFrame(iter *WebPIterator) int32 {
+	return xWebPDemuxPrevFrame(iter)
+}
+func WebPDemuxReleaseIterator(iter *WebPIterator) {
+	xWebPDemuxReleaseIterator(iter)
+}
+func WebPDemuxGetChunk(dmux uintptr, fourcc *byte, chunkNumber int32, iter *WebPChunkIterator) int32 {
+	return xWebPDemuxGetChunk(dmux, fourcc, chunkNumber, iter)
+}
+func WebPDemuxNextChunk(iter *WebPChunkIterator) int32 {
+	return xWebPDemuxNextChunk(iter)
+}
+func WebPDemuxPrevChunk(iter *WebPChunkIterator) int32 {
+	return xWebPDemuxPrevChunk(iter)
+}
+func WebPDemuxReleaseChunkIterator(iter *WebPChunkIterator) {
+	xWebPDemuxReleaseChunkIterator(iter)
+}
+func WebPAnimEncoderOptionsInitInternal(options *WebPAnimEncoderOptions, abiVersion int32) int32 {
+	return xWebPAnimEncoderOptionsInitInternal(options, abiVersion)
+}
+func WebPAnimEncoderNewInternal(width int32, height int32, options *WebPAnimEncoderOptions, abiVersion int32) uintptr {
+	return xWebPAnimEncoderNewInternal(width, height, options, abiVersion)
+}
+func WebPAnimEncoderAdd(enc uintptr, frame *WebPPicture, timestamp int32, config *WebPConfig) int32 {
+	return xWebPAnimEncoderAdd(enc, frame, timestamp, config)
+}
+func WebPAnimEncoderAssemble(enc uintptr, webpData *WebPData) int32 {
+	return xWebPAnimEncoderAssemble(enc, webpData)
+}
+func WebPAnimEncoderGetError(enc uintptr) uintptr {
+	return xWebPAnimEncoderGetError(enc)
+}
+func WebPAnimEncoderDelete(enc uintptr) {
+	xWebPAnimEncoderDelete(enc)
+}
+func WebPMuxCreateInternal(bitstream *WebPData, copyData int32, abiVersion int32) uintptr {
+	return xWebPMuxCreateInternal(bitstream, copyData, abiVersion)
+}
+func WebPNewInternal(abiVersion int32) uintptr {
+	return xWebPNewInternal(abiVersion)
+}
+func WebPMuxSetImage(mux uintptr, bitstream *WebPData, copyData int32) int32 {
+	return xWebPMuxSetImage(mux, bitstream, copyData)
+}
+func WebPMuxSetChunk(mux uintptr, fourcc *byte, chunkData *WebPData, copyData int32) int32 {
+	return xWebPMuxSetChunk(mux, fourcc, chunkData, copyData)
+}
+func WebPMuxGetChunk(mux uintptr, fourcc *byte, chunkData *WebPData) int32 {
+	return xWebPMuxGetChunk(mux, fourcc, chunkData)
+}
+func WebPMuxDeleteChunk(mux uintptr, fourcc *byte) int32 {
+	return xWebPMuxDeleteChunk(mux, fourcc)
+}
+func WebPMuxAssemble(mux uintptr, assembledData *WebPData) int32 {
+	return xWebPMuxAssemble(mux, assembledData)
+}
+func WebPMuxDelete(mux uintptr) {
+	xWebPMuxDelete(mux)
+}
 func registerAll(lib uintptr) error {
 	if err := register(lib, &xWebPGetInfo, "WebPGetInfo"); err != nil {
 		return err
@@ -511,3 +651,107 @@ func registerAll(lib uintptr) error {
 
 	return nil
 }
+func registerAllDemux(lib uintptr) error {
+	if err := register(lib, &xWebPAnimDecoderOptionsInitInternal, "WebPAnimDecoderOptionsInitInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimDecoderNewInternal, "WebPAnimDecoderNewInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimDecoderGetInfo, "WebPAnimDecoderGetInfo"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimDecoderGetNext, "WebPAnimDecoderGetNext"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimDecoderHasMoreFrames, "WebPAnimDecoderHasMoreFrames"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimDecoderReset, "WebPAnimDecoderReset"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimDecoderDelete, "WebPAnimDecoderDelete"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxInternal, "WebPDemuxInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxDelete, "WebPDemuxDelete"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxGetI, "WebPDemuxGetI"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxGetFrame, "WebPDemuxGetFrame"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxNextFrame, "WebPDemuxNextFrame"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxPrevFrame, "WebPDemuxPrevFrame"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxReleaseIterator, "WebPDemuxReleaseIterator"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxGetChunk, "WebPDemuxGetChunk"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxNextChunk, "WebPDemuxNextChunk"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxPrevChunk, "WebPDemuxPrevChunk"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPDemuxReleaseChunkIterator, "WebPDemuxReleaseChunkIterator"); err != nil {
+		return err
+	}
+
+	return nil
+}
+func registerAllMux(lib uintptr) error {
+	if err := register(lib, &xWebPAnimEncoderOptionsInitInternal, "WebPAnimEncoderOptionsInitInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimEncoderNewInternal, "WebPAnimEncoderNewInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimEncoderAdd, "WebPAnimEncoderAdd"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimEncoderAssemble, "WebPAnimEncoderAssemble"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimEncoderGetError, "WebPAnimEncoderGetError"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPAnimEncoderDelete, "WebPAnimEncoderDelete"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxCreateInternal, "WebPMuxCreateInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPNewInternal, "WebPNewInternal"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxSetImage, "WebPMuxSetImage"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxSetChunk, "WebPMuxSetChunk"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxGetChunk, "WebPMuxGetChunk"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxDeleteChunk, "WebPMuxDeleteChunk"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxAssemble, "WebPMuxAssemble"); err != nil {
+		return err
+	}
+	if err := register(lib, &xWebPMuxDelete, "WebPMuxDelete"); err != nil {
+		return err
+	}
+
+	return nil
+}
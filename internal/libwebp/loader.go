@@ -3,39 +3,172 @@ package libwebp
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
 	"sync"
 
 	"github.com/bnema/purego"
+	"github.com/bnema/purego-webp/internal/dynlib"
 )
 
 var (
 	loadOnce sync.Once
 	loadErr  error
+	libH     uintptr
+
+	demuxOnce sync.Once
+	demuxErr  error
+	demuxH    uintptr
+
+	muxOnce sync.Once
+	muxErr  error
+	muxH    uintptr
+
+	pathMu      sync.Mutex
+	libraryPath string
+
+	missingMu       sync.Mutex
+	missingOptional []string
 )
 
+// SetLibraryPath makes the loader open exactly path instead of searching the
+// candidate names: there is no fallback to $WEBP_LIBRARY_PATH or the
+// candidates, and a failure to open path is the load error. On Windows path
+// may instead be a directory, which is searched first for each candidate name
+// and made the DLL directory. It only has an effect before the library is
+// loaded.
+func SetLibraryPath(path string) {
+	pathMu.Lock()
+	libraryPath = path
+	pathMu.Unlock()
+}
+
+func explicitLibraryPath() string {
+	pathMu.Lock()
+	defer pathMu.Unlock()
+	return libraryPath
+}
+
+// ErrAlreadyLoaded is returned by Load once libwebp has been loaded.
+var ErrAlreadyLoaded = errors.New("libwebp: library already loaded")
+
 func EnsureLoaded() error {
+	loadOnce.Do(func() { load(openLib) })
+
+	return loadErr
+}
+
+// Load opens exactly the library at path and registers its symbols, in place
+// of the discovery done by EnsureLoaded, which then reuses the handle. It
+// fails with ErrAlreadyLoaded if a library was already loaded (or a load
+// attempted).
+func Load(path string) error {
+	loaded := false
 	loadOnce.Do(func() {
-		h, err := openLib()
+		loaded = true
+		load(func() (uintptr, error) { return openLibFrom([]string{path}) })
+	})
+	if !loaded {
+		return ErrAlreadyLoaded
+	}
+
+	return loadErr
+}
+
+// load runs inside loadOnce.
+func load(open func() (uintptr, error)) {
+	h, err := open()
+	if err != nil {
+		loadErr = err
+		return
+	}
+
+	if err := registerAll(h); err != nil {
+		loadErr = err
+		return
+	}
+	libH = h
+}
+
+// Unload closes the libraries opened so far and resets the loader, so that
+// the next EnsureLoaded or Load opens libwebp again. It must not run
+// concurrently with any other use of the bindings, nor while objects
+// allocated by libwebp are alive. Platforms where closing is not supported
+// keep the libraries mapped and only reset the loader.
+func Unload() error {
+	var errs []error
+	for _, h := range []uintptr{muxH, demuxH, libH} {
+		if h != 0 {
+			errs = append(errs, dynlib.Dlclose(h))
+		}
+	}
+	loadOnce, loadErr, libH = sync.Once{}, nil, 0
+	demuxOnce, demuxErr, demuxH = sync.Once{}, nil, 0
+	muxOnce, muxErr, muxH = sync.Once{}, nil, 0
+	missingMu.Lock()
+	missingOptional = nil
+	missingMu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+func Available() bool {
+	return EnsureLoaded() == nil
+}
+
+// EnsureDemuxLoaded loads libwebpdemux (animation decoding and container
+// inspection) on top of the core libwebp.
+func EnsureDemuxLoaded() error {
+	if err := EnsureLoaded(); err != nil {
+		return err
+	}
+	demuxOnce.Do(func() {
+		h, err := openLibFrom(candidateDemuxLibNames())
 		if err != nil {
-			loadErr = err
+			demuxErr = err
 			return
 		}
 
-		if err := registerAll(h); err != nil {
-			loadErr = err
+		demuxH = h
+		demuxErr = registerAllDemux(h)
+	})
+
+	return demuxErr
+}
+
+func DemuxAvailable() bool {
+	return EnsureDemuxLoaded() == nil
+}
+
+// EnsureMuxLoaded loads libwebpmux (animation encoding and chunk editing) on
+// top of the core libwebp.
+func EnsureMuxLoaded() error {
+	if err := EnsureLoaded(); err != nil {
+		return err
+	}
+	muxOnce.Do(func() {
+		h, err := openLibFrom(candidateMuxLibNames())
+		if err != nil {
+			muxErr = err
+			return
 		}
+
+		muxH = h
+		muxErr = registerAllMux(h)
 	})
 
-	return loadErr
+	return muxErr
 }
 
-func Available() bool {
-	return EnsureLoaded() == nil
+func MuxAvailable() bool {
+	return EnsureMuxLoaded() == nil
 }
 
 func register(lib uintptr, fnPtr interface{}, symbol string) error {
-	addr, err := purego.Dlsym(lib, symbol)
+	addr, err := dynlib.Dlsym(lib, symbol)
 	if err != nil {
 		return fmt.Errorf("resolve %s: %w", symbol, err)
 	}
@@ -43,44 +176,112 @@ func register(lib uintptr, fnPtr interface{}, symbol string) error {
 	return nil
 }
 
+// SymbolAddress returns the address of symbol in the loaded libwebp, for
+// callers that must hand a C function pointer back to libwebp (for example
+// WebPMemoryWrite as a picture writer).
+func SymbolAddress(symbol string) (uintptr, error) {
+	if err := EnsureLoaded(); err != nil {
+		return 0, err
+	}
+	addr, err := dynlib.Dlsym(libH, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("resolve %s: %w", symbol, err)
+	}
+	return addr, nil
+}
+
+// Handle loads libwebp and returns its dlopen handle.
+func Handle() (uintptr, error) {
+	if err := EnsureLoaded(); err != nil {
+		return 0, err
+	}
+	return libH, nil
+}
+
 // registerOptional resolves symbol from lib and registers fnPtr if found.
-// Missing symbols are silently ignored; the function pointer is left nil.
+// Missing symbols are recorded for MissingOptionalSymbols; the function
+// pointer is set to nil, dropping any registration from a previous load.
 func registerOptional(lib uintptr, fnPtr interface{}, symbol string) {
-	addr, err := purego.Dlsym(lib, symbol)
+	addr, err := dynlib.Dlsym(lib, symbol)
 	if err != nil {
+		reflect.ValueOf(fnPtr).Elem().SetZero()
+		missingMu.Lock()
+		missingOptional = append(missingOptional, symbol)
+		missingMu.Unlock()
 		return
 	}
 	purego.RegisterFunc(fnPtr, addr)
 }
 
-// ValidateDecoderConfigAvailable reports whether WebPValidateDecoderConfig
-// was found in the loaded libwebp. It was added in libwebp 1.6.0 (2025-03).
-func ValidateDecoderConfigAvailable() bool {
-	return EnsureLoaded() == nil && xWebPValidateDecoderConfig != nil
+// MissingOptionalSymbols returns the optional symbols that failed to resolve
+// in the libraries loaded so far.
+func MissingOptionalSymbols() []string {
+	missingMu.Lock()
+	defer missingMu.Unlock()
+	return slices.Clone(missingOptional)
 }
 
 func openLib() (uintptr, error) {
-	var errs []error
-	for _, name := range candidateLibNames() {
-		lib, err := purego.Dlopen(name, purego.RTLD_NOW|purego.RTLD_GLOBAL)
-		if err == nil {
-			return lib, nil
-		}
-		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	if path := explicitLibraryPath(); path != "" && libraryDir() == "" {
+		return openLibFrom([]string{path})
 	}
+	return openLibFrom(libCandidates())
+}
+
+// libCandidates returns the candidate names, preceded by $WEBP_LIBRARY_PATH
+// when set.
+func libCandidates() []string {
+	return dynlib.WithLibraryPathEnv(candidateLibNames())
+}
 
-	return 0, errors.Join(errs...)
+// openLibFrom opens the first of names that loads. When SetLibraryPath names
+// a directory it is first made the DLL directory, so that the DLLs libwebp
+// itself depends on (libsharpyuv.dll) are found next to it too.
+func openLibFrom(names []string) (uintptr, error) {
+	if dir := libraryDir(); dir != "" {
+		if err := dynlib.SetDllDirectory(dir); err != nil {
+			return 0, err
+		}
+	}
+	return dynlib.OpenFirst(names)
 }
 
 func candidateLibNames() []string {
-	switch runtime.GOOS {
-	case "linux":
-		return []string{"libwebp.so", "libwebp.so.8", "libwebp.so.7", "libwebp.so.6"}
-	case "darwin":
-		return []string{"libwebp.dylib"}
-	case "windows":
-		return []string{"libwebp.dll", "webp.dll"}
-	default:
-		return []string{"libwebp.so"}
+	return withLibraryDir(dynlib.LibNames())
+}
+
+func candidateDemuxLibNames() []string {
+	return withLibraryDir(dynlib.DemuxLibNames())
+}
+
+func candidateMuxLibNames() []string {
+	return withLibraryDir(dynlib.MuxLibNames())
+}
+
+// libraryDir returns the directory set with SetLibraryPath on Windows, where
+// libwebp.dll typically ships in an application directory that is not on
+// PATH, and "" otherwise.
+func libraryDir() string {
+	path := explicitLibraryPath()
+	if runtime.GOOS != "windows" || path == "" {
+		return ""
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// withLibraryDir returns names, preceded by each of them joined to
+// libraryDir when one is set.
+func withLibraryDir(names []string) []string {
+	dir := libraryDir()
+	if dir == "" {
+		return names
+	}
+	paths := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		paths = append(paths, filepath.Join(dir, name))
 	}
+	return append(paths, names...)
 }
@@ -0,0 +1,20 @@
+//go:build darwin
+
+package libwebp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCandidateLibNamesIncludeHomebrew(t *testing.T) {
+	names := candidateLibNames()
+	if len(names) == 0 || names[0] != "libwebp.dylib" {
+		t.Fatalf("candidateLibNames() = %q, want libwebp.dylib first", names)
+	}
+	for _, path := range []string{"/opt/homebrew/lib/libwebp.dylib", "/usr/local/lib/libwebp.dylib"} {
+		if !slices.Contains(names, path) {
+			t.Fatalf("candidateLibNames() = %q, missing %s", names, path)
+		}
+	}
+}
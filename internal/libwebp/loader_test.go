@@ -0,0 +1,71 @@
+package libwebp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnema/purego-webp/internal/dynlib"
+)
+
+// systemLibPath returns the path of an installed libwebp shared library.
+func systemLibPath(t *testing.T) string {
+	t.Helper()
+	path := dynlib.InstalledLibPath()
+	if path == "" {
+		t.Skip("no installed libwebp found")
+	}
+	return path
+}
+
+func TestOpenLibUsesLibraryPath(t *testing.T) {
+	src := systemLibPath(t)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("read %s: %v", src, err)
+	}
+	copied := filepath.Join(t.TempDir(), filepath.Base(src))
+	if err := os.WriteFile(copied, data, 0o755); err != nil {
+		t.Fatalf("copy library: %v", err)
+	}
+	t.Cleanup(func() { SetLibraryPath("") })
+
+	SetLibraryPath(copied)
+	h, err := openLib()
+	if err != nil {
+		t.Fatalf("openLib() with copied library error = %v", err)
+	}
+	dynlib.Dlclose(h)
+
+	bogus := filepath.Join(t.TempDir(), "libwebp-missing.so")
+	SetLibraryPath(bogus)
+	_, err = openLib()
+	if err == nil {
+		t.Fatal("openLib() with missing library succeeded")
+	}
+	if !strings.Contains(err.Error(), bogus) {
+		t.Fatalf("openLib() error = %q, want it to name %s", err, bogus)
+	}
+}
+
+func TestLibraryPathEnvIsTriedFirst(t *testing.T) {
+	bogus := filepath.Join(t.TempDir(), "libwebp-from-env.so")
+	t.Setenv(dynlib.LibraryPathEnv, bogus)
+
+	names := libCandidates()
+	if len(names) < 2 || names[0] != bogus {
+		t.Fatalf("libCandidates() = %q, want %s first", names, bogus)
+	}
+	if _, err := openLibFrom(names[:1]); err == nil || !strings.Contains(err.Error(), bogus) {
+		t.Fatalf("openLibFrom(env path) error = %v, want it to name %s", err, bogus)
+	}
+
+	// A bad env path falls back to the built-in names.
+	systemLibPath(t)
+	h, err := openLib()
+	if err != nil {
+		t.Fatalf("openLib() with bogus %s error = %v", dynlib.LibraryPathEnv, err)
+	}
+	dynlib.Dlclose(h)
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package libwebp
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCandidateLibNamesIncludeLibraryDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(func() { SetLibraryPath("") })
+	SetLibraryPath(dir)
+
+	for _, names := range [][]string{candidateLibNames(), candidateDemuxLibNames(), candidateMuxLibNames()} {
+		if len(names) < 2 || filepath.Dir(names[0]) != dir {
+			t.Fatalf("candidate names = %q, want %s entries first", names, dir)
+		}
+	}
+	if names := candidateLibNames(); !slices.Contains(names, filepath.Join(dir, "libwebp.dll")) || !slices.Contains(names, "libwebp.dll") {
+		t.Fatalf("candidateLibNames() = %q, want libwebp.dll in %s and on the search path", names, dir)
+	}
+
+	// A file path is still opened as is.
+	SetLibraryPath(filepath.Join(dir, "libwebp.dll"))
+	if names := candidateLibNames(); names[0] != "libwebp.dll" {
+		t.Fatalf("candidateLibNames() with a file path = %q, want the default names", names)
+	}
+}
@@ -1,5 +1,7 @@
 package libwebp
 
+import "unsafe"
+
 type VP8StatusCode int32
 
 const (
@@ -13,6 +15,8 @@ const (
 	VP8StatusNotEnoughData   VP8StatusCode = 7
 	WebPDecoderABIVersion    int32         = 0x0210
 	WebPEncoderABIVersion    int32         = 0x0210
+	WebPDemuxABIVersion      int32         = 0x0107
+	WebPMuxABIVersion        int32         = 0x0108
 )
 
 type WebPBitstreamFeatures struct {
@@ -59,6 +63,18 @@ type WebPDecBuffer struct {
 	PrivateMemory    uintptr
 }
 
+// RGBA returns the packed RGB-family view of the output buffer union, valid
+// when Colorspace is an RGB mode.
+func (b *WebPDecBuffer) RGBA() *WebPRGBABuffer {
+	return (*WebPRGBABuffer)(unsafe.Pointer(&b.BufferUnion[0]))
+}
+
+// YUVA returns the planar view of the output buffer union, valid when
+// Colorspace is a YUV mode.
+func (b *WebPDecBuffer) YUVA() *WebPYUVABuffer {
+	return (*WebPYUVABuffer)(unsafe.Pointer(&b.BufferUnion[0]))
+}
+
 type WebPDecoderOptions struct {
 	BypassFiltering        int32
 	NoFancyUpsampling      int32
@@ -115,6 +131,30 @@ type WebPConfig struct {
 	QMax             int32
 }
 
+// WebPAuxStats matches encode.h; WebPEncode fills it when WebPPicture.Stats
+// points to it.
+type WebPAuxStats struct {
+	CodedSize        int32
+	PSNR             [5]float32
+	BlockCount       [3]int32
+	HeaderBytes      [2]int32
+	ResidualBytes    [3][4]int32
+	SegmentSize      [4]int32
+	SegmentQuant     [4]int32
+	SegmentLevel     [4]int32
+	AlphaDataSize    int32
+	LayerDataSize    int32
+	LosslessFeatures uint32
+	HistogramBits    int32
+	TransformBits    int32
+	CacheBits        int32
+	PaletteSize      int32
+	LosslessSize     int32
+	LosslessHdrSize  int32
+	LosslessDataSize int32
+	Pad              [2]uint32
+}
+
 type WebPMemoryWriter struct {
 	Mem     uintptr
 	Size    uintptr
@@ -162,3 +202,86 @@ type WebPPicture struct {
 	MemoryArgb uintptr
 	Pad7       [2]uintptr
 }
+
+// WebPData matches mux_types.h: a borrowed or owned byte range.
+type WebPData struct {
+	Bytes uintptr
+	Size  uintptr
+}
+
+// Demux feature queries for WebPDemuxGetI (WebPFormatFeature).
+const (
+	WebPFFFormatFlags     int32 = 0
+	WebPFFCanvasWidth     int32 = 1
+	WebPFFCanvasHeight    int32 = 2
+	WebPFFLoopCount       int32 = 3
+	WebPFFBackgroundColor int32 = 4
+	WebPFFFrameCount      int32 = 5
+)
+
+// VP8X feature flags reported by WebPFFFormatFlags (WebPFeatureFlags).
+const (
+	WebPAnimationFlag uint32 = 0x00000002
+	WebPXMPFlag       uint32 = 0x00000004
+	WebPEXIFFlag      uint32 = 0x00000008
+	WebPAlphaFlag     uint32 = 0x00000010
+	WebPICCPFlag      uint32 = 0x00000020
+)
+
+// WebPIterator matches demux.h: one frame of a demuxed container.
+type WebPIterator struct {
+	FrameNum      int32
+	NumFrames     int32
+	XOffset       int32
+	YOffset       int32
+	Width         int32
+	Height        int32
+	Duration      int32
+	DisposeMethod int32
+	Complete      int32
+	Fragment      WebPData
+	HasAlpha      int32
+	BlendMethod   int32
+	Pad           [2]uint32
+	Private       uintptr
+}
+
+// WebPChunkIterator matches demux.h: one metadata chunk of a demuxed
+// container.
+type WebPChunkIterator struct {
+	ChunkNum  int32
+	NumChunks int32
+	Chunk     WebPData
+	Pad       [6]uint32
+	Private   uintptr
+}
+
+type WebPAnimDecoderOptions struct {
+	ColorMode  int32
+	UseThreads int32
+	Padding    [7]uint32
+}
+
+type WebPAnimInfo struct {
+	CanvasWidth  uint32
+	CanvasHeight uint32
+	LoopCount    uint32
+	BgColor      uint32
+	FrameCount   uint32
+	Pad          [4]uint32
+}
+
+type WebPMuxAnimParams struct {
+	BgColor   uint32
+	LoopCount int32
+}
+
+type WebPAnimEncoderOptions struct {
+	AnimParams   WebPMuxAnimParams
+	MinimizeSize int32
+	Kmin         int32
+	Kmax         int32
+	AllowMixed   int32
+	Verbose      int32
+	Padding      [4]uint32
+}
@@ -0,0 +1,186 @@
+package libwebp
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// AnimDecoderOptions is the low-level WebPAnimDecoderOptions struct.
+type AnimDecoderOptions = lowlevel.WebPAnimDecoderOptions
+
+// AnimInfo is the low-level WebPAnimInfo struct describing an animation.
+type AnimInfo = lowlevel.WebPAnimInfo
+
+// demuxInputs keeps the input of each live anim decoder or demuxer pinned:
+// libwebpdemux references the caller's bytes until the handle is deleted.
+var demuxInputs = struct {
+	sync.Mutex
+	pinners map[uintptr]*runtime.Pinner
+}{pinners: map[uintptr]*runtime.Pinner{}}
+
+// pinDemuxInput pins data and returns it as a WebPData. The caller must hand
+// the pinner to keepDemuxInput, or unpin it if no handle was created.
+func pinDemuxInput(data []byte) (*runtime.Pinner, lowlevel.WebPData) {
+	pinner := new(runtime.Pinner)
+	pinner.Pin(&data[0])
+	return pinner, lowlevel.WebPData{Bytes: uintptr(unsafe.Pointer(&data[0])), Size: uintptr(len(data))}
+}
+
+func keepDemuxInput(handle uintptr, pinner *runtime.Pinner) {
+	demuxInputs.Lock()
+	demuxInputs.pinners[handle] = pinner
+	demuxInputs.Unlock()
+}
+
+func releaseDemuxInput(handle uintptr) {
+	demuxInputs.Lock()
+	pinner := demuxInputs.pinners[handle]
+	delete(demuxInputs.pinners, handle)
+	demuxInputs.Unlock()
+	if pinner != nil {
+		pinner.Unpin()
+	}
+}
+
+// DemuxAvailable reports whether libwebpdemux, which provides animation
+// decoding, can be loaded in the current environment.
+func DemuxAvailable() bool {
+	return lowlevel.DemuxAvailable()
+}
+
+// WebPAnimDecoderOptionsInit fills options with the libwebp defaults (RGBA
+// output, no threads). It returns false on a libwebpdemux ABI mismatch.
+func WebPAnimDecoderOptionsInit(options *AnimDecoderOptions) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if options == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPAnimDecoderOptionsInitInternal(options, lowlevel.WebPDemuxABIVersion) != 0, nil
+}
+
+// WebPAnimDecoderNew creates an animation decoder for data. A nil options
+// selects libwebp defaults (RGBA output, no threads). data must not be
+// modified until the decoder is released with WebPAnimDecoderDelete.
+func WebPAnimDecoderNew(data []byte, options *AnimDecoderOptions) (uintptr, error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, ErrInvalidData
+	}
+
+	pinner, webpData := pinDemuxInput(data)
+	dec := lowlevel.WebPAnimDecoderNewInternal(&webpData, options, lowlevel.WebPDemuxABIVersion)
+	if dec == 0 {
+		pinner.Unpin()
+		return 0, ErrDecodeFailed
+	}
+
+	keepDemuxInput(dec, pinner)
+	return dec, nil
+}
+
+// WebPAnimDecoderGetInfo returns the canvas size, loop count, background
+// color and frame count of the animation.
+func WebPAnimDecoderGetInfo(dec uintptr) (AnimInfo, error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return AnimInfo{}, err
+	}
+	if dec == 0 {
+		return AnimInfo{}, ErrInvalidData
+	}
+
+	var info AnimInfo
+	if lowlevel.WebPAnimDecoderGetInfo(dec, &info) == 0 {
+		return AnimInfo{}, ErrDecodeFailed
+	}
+	return info, nil
+}
+
+// WebPAnimDecoderGetNext decodes the next frame and returns a pointer to the
+// fully reconstructed canvas (owned by the decoder and valid until the next
+// call) and the frame's end timestamp in milliseconds.
+func WebPAnimDecoderGetNext(dec uintptr) (buf uintptr, timestamp int, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return 0, 0, err
+	}
+	if dec == 0 {
+		return 0, 0, ErrInvalidData
+	}
+
+	var out *byte
+	var ts int32
+	if lowlevel.WebPAnimDecoderGetNext(dec, &out, &ts) == 0 || out == nil {
+		return 0, 0, ErrDecodeFailed
+	}
+	return uintptr(unsafe.Pointer(out)), int(ts), nil
+}
+
+// WebPAnimDecoderGetNextInto decodes the next frame and copies the canvas
+// into dst, which must hold at least canvasWidth*canvasHeight*4 bytes.
+func WebPAnimDecoderGetNextInto(dec uintptr, dst []byte) (timestamp int, err error) {
+	info, err := WebPAnimDecoderGetInfo(dec)
+	if err != nil {
+		return 0, err
+	}
+	_, size, err := checkedDecodeLayout(int(info.CanvasWidth), int(info.CanvasHeight), 4)
+	if err != nil {
+		return 0, err
+	}
+	if len(dst) < size {
+		return 0, ErrBufferTooSmall
+	}
+
+	buf, timestamp, err := WebPAnimDecoderGetNext(dec)
+	if err != nil {
+		return 0, err
+	}
+	copy(dst, cBytes(buf, size))
+	return timestamp, nil
+}
+
+// WebPAnimDecoderHasMoreFrames reports whether frames remain to be decoded.
+func WebPAnimDecoderHasMoreFrames(dec uintptr) (bool, error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if dec == 0 {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPAnimDecoderHasMoreFrames(dec) != 0, nil
+}
+
+// WebPAnimDecoderReset rewinds the decoder so the next WebPAnimDecoderGetNext
+// returns the first frame again.
+func WebPAnimDecoderReset(dec uintptr) error {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return err
+	}
+	if dec == 0 {
+		return ErrInvalidData
+	}
+
+	lowlevel.WebPAnimDecoderReset(dec)
+	return nil
+}
+
+// WebPAnimDecoderDelete destroys an animation decoder and releases its input.
+func WebPAnimDecoderDelete(dec uintptr) error {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return err
+	}
+	if dec == 0 {
+		return nil
+	}
+
+	lowlevel.WebPAnimDecoderDelete(dec)
+	releaseDemuxInput(dec)
+	return nil
+}
@@ -0,0 +1,107 @@
+package libwebp
+
+import (
+	"fmt"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// AnimEncoderOptions is the low-level WebPAnimEncoderOptions struct.
+type AnimEncoderOptions = lowlevel.WebPAnimEncoderOptions
+
+// MuxAvailable reports whether libwebpmux, which provides animation encoding
+// and chunk editing, can be loaded in the current environment.
+func MuxAvailable() bool {
+	return lowlevel.MuxAvailable()
+}
+
+// WebPAnimEncoderOptionsInit fills options with the libwebp defaults
+// (infinite loop, white background, keyframes chosen automatically). It
+// returns false on a libwebpmux ABI mismatch.
+func WebPAnimEncoderOptionsInit(options *AnimEncoderOptions) (ok bool, err error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return false, err
+	}
+	if options == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPAnimEncoderOptionsInitInternal(options, lowlevel.WebPMuxABIVersion) != 0, nil
+}
+
+// WebPAnimEncoderNew creates an animation encoder for a width x height
+// canvas. A nil options selects libwebp defaults.
+func WebPAnimEncoderNew(width, height int, options *AnimEncoderOptions) (uintptr, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return 0, err
+	}
+	if width <= 0 || height <= 0 {
+		return 0, ErrInvalidDimension
+	}
+
+	enc := lowlevel.WebPAnimEncoderNewInternal(int32(width), int32(height), options, lowlevel.WebPMuxABIVersion)
+	if enc == 0 {
+		return 0, ErrEncodeFailed
+	}
+	return enc, nil
+}
+
+// WebPAnimEncoderAdd encodes picture as the frame starting at timestamp
+// milliseconds, using config (nil selects the libwebp defaults). Timestamps
+// must increase. A nil picture ends the animation, timestamp then being the
+// end time of the last frame. The picture is only read during the call.
+func WebPAnimEncoderAdd(enc uintptr, picture *Picture, timestamp int, config *Config) error {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return err
+	}
+	if enc == 0 {
+		return ErrInvalidData
+	}
+
+	if lowlevel.WebPAnimEncoderAdd(enc, picture, int32(timestamp), config) == 0 {
+		return animEncoderError(enc)
+	}
+	return nil
+}
+
+// WebPAnimEncoderAssemble finishes the animation and returns the WebP file.
+// Call WebPAnimEncoderAdd with a nil picture first to set the duration of
+// the last frame.
+func WebPAnimEncoderAssemble(enc uintptr) ([]byte, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return nil, err
+	}
+	if enc == 0 {
+		return nil, ErrInvalidData
+	}
+
+	var data WebPData
+	if lowlevel.WebPAnimEncoderAssemble(enc, &data) == 0 {
+		return nil, animEncoderError(enc)
+	}
+	defer WebPDataClear(&data)
+
+	return WebPDataBytes(&data), nil
+}
+
+// WebPAnimEncoderDelete destroys an animation encoder.
+func WebPAnimEncoderDelete(enc uintptr) error {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return err
+	}
+	if enc == 0 {
+		return nil
+	}
+
+	lowlevel.WebPAnimEncoderDelete(enc)
+	return nil
+}
+
+// animEncoderError wraps ErrEncodeFailed with the encoder's last error
+// message, if any.
+func animEncoderError(enc uintptr) error {
+	if msg := cString(lowlevel.WebPAnimEncoderGetError(enc)); msg != "" {
+		return fmt.Errorf("%w: %s", ErrEncodeFailed, msg)
+	}
+	return ErrEncodeFailed
+}
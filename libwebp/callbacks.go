@@ -0,0 +1,144 @@
+package libwebp
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+
+	"github.com/bnema/purego"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// C callbacks are created once per process (purego callbacks are never
+// freed) and dispatch to Go functions registered under an id stored in the
+// picture's UserData.
+var progressHooks = struct {
+	sync.Mutex
+	once sync.Once
+	addr uintptr
+	next uintptr
+	fns  map[uintptr]func(percent int) bool
+}{fns: map[uintptr]func(percent int) bool{}}
+
+func progressHookTrampoline(percent int32, picture *Picture) int32 {
+	if picture == nil {
+		return 1
+	}
+	progressHooks.Lock()
+	fn := progressHooks.fns[picture.UserData]
+	progressHooks.Unlock()
+	if fn == nil || fn(int(percent)) {
+		return 1
+	}
+	return 0
+}
+
+// SetPictureProgressHook installs fn as the progress hook of picture. libwebp
+// calls it with the encode progress in percent; returning false aborts
+// WebPEncode, which then fails with a user-abort ErrorCode.
+//
+// The hook is tracked through picture.UserData, which must not be modified
+// while it is installed. Call SetPictureProgressHook(picture, nil) once the
+// encode is done to release fn.
+func SetPictureProgressHook(picture *Picture, fn func(percent int) bool) {
+	if picture == nil {
+		return
+	}
+
+	progressHooks.Lock()
+	defer progressHooks.Unlock()
+	if picture.ProgressHook != 0 && picture.ProgressHook == progressHooks.addr {
+		delete(progressHooks.fns, picture.UserData)
+		picture.ProgressHook = 0
+		picture.UserData = 0
+	}
+	if fn == nil {
+		return
+	}
+
+	progressHooks.once.Do(func() {
+		progressHooks.addr = purego.NewCallback(progressHookTrampoline)
+	})
+	progressHooks.next++
+	progressHooks.fns[progressHooks.next] = fn
+	picture.ProgressHook = progressHooks.addr
+	picture.UserData = progressHooks.next
+}
+
+// pictureWriters maps the CustomPtr id of a picture being encoded by
+// WebPEncodeToWriter to its destination.
+var pictureWriters = struct {
+	sync.Mutex
+	once sync.Once
+	addr uintptr
+	next uintptr
+	dsts map[uintptr]*pictureWriter
+}{dsts: map[uintptr]*pictureWriter{}}
+
+type pictureWriter struct {
+	w   io.Writer
+	err error
+}
+
+func pictureWriterTrampoline(data *byte, dataSize uintptr, picture *Picture) int32 {
+	if picture == nil {
+		return 0
+	}
+	pictureWriters.Lock()
+	dst := pictureWriters.dsts[picture.CustomPtr]
+	pictureWriters.Unlock()
+	if dst == nil {
+		return 0
+	}
+	if dataSize == 0 {
+		return 1
+	}
+	if _, err := dst.w.Write(unsafe.Slice(data, dataSize)); err != nil {
+		dst.err = err
+		return 0
+	}
+	return 1
+}
+
+// WebPEncodeToWriter runs WebPEncode and streams the encoded chunks straight
+// into w as libwebp produces them, without buffering the whole output. A
+// write error aborts the encode and is returned wrapped with ErrEncodeFailed.
+// Output already written before a failure is not rolled back.
+func WebPEncodeToWriter(config *Config, picture *Picture, w io.Writer) error {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return err
+	}
+	if config == nil || picture == nil || w == nil {
+		return ErrInvalidData
+	}
+
+	dst := &pictureWriter{w: w}
+	pictureWriters.Lock()
+	pictureWriters.once.Do(func() {
+		pictureWriters.addr = purego.NewCallback(pictureWriterTrampoline)
+	})
+	pictureWriters.next++
+	id := pictureWriters.next
+	pictureWriters.dsts[id] = dst
+	pictureWriters.Unlock()
+
+	prevWriter, prevCustomPtr := picture.Writer, picture.CustomPtr
+	picture.Writer = pictureWriters.addr
+	picture.CustomPtr = id
+	defer func() {
+		picture.Writer, picture.CustomPtr = prevWriter, prevCustomPtr
+		pictureWriters.Lock()
+		delete(pictureWriters.dsts, id)
+		pictureWriters.Unlock()
+	}()
+
+	if lowlevel.WebPEncode(config, picture) == 0 {
+		if dst.err != nil {
+			return fmt.Errorf("%w: %w", encodeErrorFrom(picture), dst.err)
+		}
+		return encodeErrorFrom(picture)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package libwebp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebPConfigPreset(t *testing.T) {
+	for preset := PresetDefault; preset <= PresetText; preset++ {
+		var config Config
+		if ok, err := WebPConfigPreset(&config, preset, 60); err != nil || !ok {
+			t.Fatalf("WebPConfigPreset(%d) = (%v, %v), want (true, nil)", preset, ok, err)
+		}
+		if config.Quality != 60 {
+			t.Fatalf("WebPConfigPreset(%d) Quality = %v, want 60", preset, config.Quality)
+		}
+	}
+	var config Config
+	if ok, err := WebPConfigPreset(&config, PresetText+1, 60); ok || !errors.Is(err, ErrInvalidEnum) {
+		t.Fatalf("WebPConfigPreset(invalid) = (%v, %v), want %v", ok, err, ErrInvalidEnum)
+	}
+	if HintLast.Valid() || !HintGraph.Valid() || Hint(-1).Valid() {
+		t.Fatal("Hint.Valid() accepts HintLast or negative hints, or rejects HintGraph")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	var config Config
+	if ok, err := WebPConfigInit(&config); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatalf("ValidateConfig(default) error = %v", err)
+	}
+	config.Quality = 200
+	if err := ValidateConfig(&config); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("ValidateConfig(Quality 200) error = %v, want %v", err, ErrInvalidConfig)
+	}
+	if err := ValidateConfig(nil); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("ValidateConfig(nil) error = %v, want %v", err, ErrInvalidData)
+	}
+}
+
+func TestCloneConfig(t *testing.T) {
+	var src Config
+	if ok, err := WebPConfigInit(&src); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+	want := src
+	clone := CloneConfig(&src)
+	if *clone != src {
+		t.Fatalf("CloneConfig() = %+v, want %+v", *clone, src)
+	}
+	clone.Quality, clone.Method = 10, 6
+	if src != want {
+		t.Fatalf("mutating the clone changed the source: %+v", src)
+	}
+	if CloneConfig(nil) != nil {
+		t.Fatal("CloneConfig(nil) != nil")
+	}
+}
+
+func TestCloneDecoderConfig(t *testing.T) {
+	var src DecoderConfig
+	if ok, err := WebPInitDecoderConfig(&src); err != nil || !ok {
+		t.Fatalf("WebPInitDecoderConfig() = (%v, %v)", ok, err)
+	}
+	src.Output.Colorspace = int32(ModeBGRA)
+	src.Output.PrivateMemory = 1
+	src.Options.UseScaling, src.Options.ScaledWidth = 1, 32
+	want := src
+
+	clone := CloneDecoderConfig(&src)
+	if clone.Options != src.Options || clone.Output.Colorspace != src.Output.Colorspace {
+		t.Fatalf("CloneDecoderConfig() = %+v, want options and colorspace of %+v", *clone, src)
+	}
+	if clone.Output.PrivateMemory != 0 {
+		t.Fatal("CloneDecoderConfig() carried over the output buffer")
+	}
+	clone.Options.ScaledWidth, clone.Options.Flip = 64, 1
+	if src != want {
+		t.Fatalf("mutating the clone changed the source: %+v", src)
+	}
+	if CloneDecoderConfig(nil) != nil {
+		t.Fatal("CloneDecoderConfig(nil) != nil")
+	}
+}
+
+func TestValidateDecoderConfig(t *testing.T) {
+	var config DecoderConfig
+	if ok, err := WebPInitDecoderConfig(&config); err != nil || !ok {
+		t.Fatalf("WebPInitDecoderConfig() = (%v, %v)", ok, err)
+	}
+	if !WebPValidateDecoderConfigAvailable() {
+		if err := ValidateDecoderConfig(&config); !errors.Is(err, ErrNotAvailable) {
+			t.Fatalf("ValidateDecoderConfig() error = %v, want %v", err, ErrNotAvailable)
+		}
+		t.Skip("WebPValidateDecoderConfig needs libwebp 1.6.0")
+	}
+	if err := ValidateDecoderConfig(&config); err != nil {
+		t.Fatalf("ValidateDecoderConfig(default) error = %v", err)
+	}
+	config.Options.DitheringStrength = 101
+	if err := ValidateDecoderConfig(&config); !errors.Is(err, ErrInvalidDecoderConfig) {
+		t.Fatalf("ValidateDecoderConfig(DitheringStrength 101) error = %v, want %v", err, ErrInvalidDecoderConfig)
+	}
+}
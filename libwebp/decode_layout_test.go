@@ -52,3 +52,112 @@ func TestWebPDecodeRGBAIntoRejectsUndersizedOutput(t *testing.T) {
 		t.Fatalf("WebPDecodeRGBAInto() error = %v, want %v", err, ErrBufferTooSmall)
 	}
 }
+
+func TestOutputStride(t *testing.T) {
+	tests := []struct {
+		mode ColorspaceMode
+		want int
+	}{
+		{ModeRGB, 30}, {ModeBGR, 30},
+		{ModeRGBA, 40}, {ModeBGRA, 40}, {ModeARGB, 40}, {ModergbA, 40}, {ModebgrA, 40}, {ModeArgb, 40},
+		{ModeRGBA4444, 20}, {ModeRGB565, 20}, {ModergbA4444, 20},
+	}
+	for _, tt := range tests {
+		if got, err := OutputStride(10, tt.mode); err != nil || got != tt.want {
+			t.Fatalf("OutputStride(10, %d) = (%d, %v), want (%d, nil)", tt.mode, got, err, tt.want)
+		}
+	}
+	for _, mode := range []ColorspaceMode{ModeYUV, ModeYUVA, ModeLast, -1} {
+		if _, err := OutputStride(10, mode); !errors.Is(err, ErrInvalidData) {
+			t.Fatalf("OutputStride(10, %d) error = %v, want %v", mode, err, ErrInvalidData)
+		}
+	}
+	if _, err := OutputStride(0, ModeRGBA); !errors.Is(err, ErrInvalidDimension) {
+		t.Fatalf("OutputStride(0, ModeRGBA) error = %v, want %v", err, ErrInvalidDimension)
+	}
+}
+
+func TestColorspaceModePredicates(t *testing.T) {
+	tests := []struct {
+		mode                      ColorspaceMode
+		alpha, rgb, premultiplied bool
+	}{
+		{ModeRGB, false, true, false},
+		{ModeRGBA, true, true, false},
+		{ModeBGR, false, true, false},
+		{ModeBGRA, true, true, false},
+		{ModeARGB, true, true, false},
+		{ModeRGBA4444, true, true, false},
+		{ModeRGB565, false, true, false},
+		{ModergbA, true, true, true},
+		{ModebgrA, true, true, true},
+		{ModeArgb, true, true, true},
+		{ModergbA4444, true, true, true},
+		{ModeYUV, false, false, false},
+		{ModeYUVA, true, false, false},
+	}
+	for _, tt := range tests {
+		if got := WebPIsAlphaMode(tt.mode); got != tt.alpha {
+			t.Errorf("WebPIsAlphaMode(%d) = %v, want %v", tt.mode, got, tt.alpha)
+		}
+		if got := WebPIsRGBMode(tt.mode); got != tt.rgb {
+			t.Errorf("WebPIsRGBMode(%d) = %v, want %v", tt.mode, got, tt.rgb)
+		}
+		if got := WebPIsPremultipliedMode(tt.mode); got != tt.premultiplied {
+			t.Errorf("WebPIsPremultipliedMode(%d) = %v, want %v", tt.mode, got, tt.premultiplied)
+		}
+	}
+}
+
+func TestWebPDecodeRGB565(t *testing.T) {
+	const w, h = 5, 3
+	pix := make([]byte, w*h*4)
+	for i := 0; i < len(pix); i += 4 {
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = 0xff, 0x80, 0x08, 0xff
+	}
+	data, err := WebPEncodeLosslessRGBA(pix, w, h, w*4)
+	if err != nil {
+		t.Fatalf("WebPEncodeLosslessRGBA() error = %v", err)
+	}
+
+	got, gw, gh, stride, err := WebPDecodeRGB565(data)
+	if err != nil {
+		t.Fatalf("WebPDecodeRGB565() error = %v", err)
+	}
+	if gw != w || gh != h || stride != w*2 || len(got) != stride*h {
+		t.Fatalf("layout = %dx%d stride %d len %d, want %dx%d stride %d len %d", gw, gh, stride, len(got), w, h, w*2, w*2*h)
+	}
+	// R 0xff>>3 = 31, G 0x80>>2 = 32, B 0x08>>3 = 1.
+	const want = 31<<11 | 32<<5 | 1
+	for _, p := range [][2]int{{0, 0}, {w - 1, h - 1}} {
+		off := p[1]*stride + p[0]*2
+		if v := uint16(got[off])<<8 | uint16(got[off+1]); v != want {
+			t.Errorf("pixel %v = %#04x, want %#04x", p, v, want)
+		}
+	}
+}
+
+func TestWebPDecodeRGBA4444(t *testing.T) {
+	const w, h = 4, 4
+	pix := make([]byte, w*h*4)
+	for i := 0; i < len(pix); i += 4 {
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = 0xff, 0x80, 0x10, 0x80
+	}
+	data, err := WebPEncodeLosslessRGBA(pix, w, h, w*4)
+	if err != nil {
+		t.Fatalf("WebPEncodeLosslessRGBA() error = %v", err)
+	}
+
+	got, gw, gh, stride, err := WebPDecodeRGBA4444(data)
+	if err != nil {
+		t.Fatalf("WebPDecodeRGBA4444() error = %v", err)
+	}
+	if gw != w || gh != h || stride != w*2 || len(got) != stride*h {
+		t.Fatalf("layout = %dx%d stride %d len %d, want %dx%d stride %d len %d", gw, gh, stride, len(got), w, h, w*2, w*2*h)
+	}
+	// Alpha stays straight: R 0xf, G 0x8, B 0x1, A 0x8.
+	off := 2*stride + 1*2
+	if got[off] != 0xf8 || got[off+1] != 0x18 {
+		t.Errorf("pixel (1, 2) = %#02x %#02x, want 0xf8 0x18", got[off], got[off+1])
+	}
+}
@@ -0,0 +1,233 @@
+package libwebp
+
+import (
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// Iterator is the low-level WebPIterator struct describing one frame of a
+// demuxed container.
+type Iterator = lowlevel.WebPIterator
+
+// Demux feature queries for WebPDemuxGetI.
+const (
+	DemuxFormatFlags     = int(lowlevel.WebPFFFormatFlags)
+	DemuxCanvasWidth     = int(lowlevel.WebPFFCanvasWidth)
+	DemuxCanvasHeight    = int(lowlevel.WebPFFCanvasHeight)
+	DemuxLoopCount       = int(lowlevel.WebPFFLoopCount)
+	DemuxBackgroundColor = int(lowlevel.WebPFFBackgroundColor)
+	DemuxFrameCount      = int(lowlevel.WebPFFFrameCount)
+)
+
+// Container feature flags reported by WebPDemuxGetI(dmux, DemuxFormatFlags).
+const (
+	FlagAnimation = lowlevel.WebPAnimationFlag
+	FlagXMP       = lowlevel.WebPXMPFlag
+	FlagEXIF      = lowlevel.WebPEXIFFlag
+	FlagAlpha     = lowlevel.WebPAlphaFlag
+	FlagICCP      = lowlevel.WebPICCPFlag
+)
+
+// DisposeMethod is what happens to the area of an animation frame once it
+// has been shown (WebPMuxAnimDispose), as stored in Iterator.DisposeMethod.
+type DisposeMethod int32
+
+const (
+	// DisposeNone leaves the canvas as is.
+	DisposeNone DisposeMethod = 0
+	// DisposeBackground clears the frame rectangle to the background before
+	// the next frame is drawn.
+	DisposeBackground DisposeMethod = 1
+)
+
+// BlendMethod is how an animation frame is drawn over the canvas
+// (WebPMuxAnimBlend), as stored in Iterator.BlendMethod.
+type BlendMethod int32
+
+const (
+	// BlendAlpha alpha-blends the frame over the canvas.
+	BlendAlpha BlendMethod = 0
+	// BlendNone overwrites the frame rectangle, alpha included.
+	BlendNone BlendMethod = 1
+)
+
+// WebPDemux parses the complete WebP container in data and returns a demuxer
+// handle. data must not be modified until the demuxer is released with
+// WebPDemuxDelete.
+func WebPDemux(data []byte) (uintptr, error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, ErrInvalidData
+	}
+
+	pinner, webpData := pinDemuxInput(data)
+	dmux := lowlevel.WebPDemuxInternal(&webpData, 0, nil, lowlevel.WebPDemuxABIVersion)
+	if dmux == 0 {
+		pinner.Unpin()
+		return 0, ErrInvalidData
+	}
+
+	keepDemuxInput(dmux, pinner)
+	return dmux, nil
+}
+
+// WebPDemuxDelete destroys a demuxer and releases its input.
+func WebPDemuxDelete(dmux uintptr) error {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return err
+	}
+	if dmux == 0 {
+		return nil
+	}
+
+	lowlevel.WebPDemuxDelete(dmux)
+	releaseDemuxInput(dmux)
+	return nil
+}
+
+// WebPDemuxGetI returns the container feature selected by one of the Demux*
+// constants.
+func WebPDemuxGetI(dmux uintptr, feature int) (uint32, error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return 0, err
+	}
+	if dmux == 0 || feature < DemuxFormatFlags || feature > DemuxFrameCount {
+		return 0, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxGetI(dmux, int32(feature)), nil
+}
+
+// WebPDemuxGetFrame points iter at frame frameNumber (1-based; 0 selects the
+// last frame). It returns false if the frame does not exist. A successful
+// iter must be released with WebPDemuxReleaseIterator.
+func WebPDemuxGetFrame(dmux uintptr, frameNumber int, iter *Iterator) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if dmux == 0 || iter == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxGetFrame(dmux, int32(frameNumber), iter) != 0, nil
+}
+
+// WebPDemuxNextFrame advances iter to the next frame, returning false at the
+// end.
+func WebPDemuxNextFrame(iter *Iterator) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if iter == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxNextFrame(iter) != 0, nil
+}
+
+// WebPDemuxPrevFrame moves iter to the previous frame, returning false at the
+// start.
+func WebPDemuxPrevFrame(iter *Iterator) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if iter == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxPrevFrame(iter) != 0, nil
+}
+
+// WebPDemuxReleaseIterator releases the memory held by iter.
+func WebPDemuxReleaseIterator(iter *Iterator) error {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return err
+	}
+	if iter == nil {
+		return nil
+	}
+
+	lowlevel.WebPDemuxReleaseIterator(iter)
+	return nil
+}
+
+// ChunkIterator is the low-level WebPChunkIterator struct describing one
+// metadata chunk of a demuxed container.
+type ChunkIterator = lowlevel.WebPChunkIterator
+
+// WebPDemuxGetChunk points iter at occurrence chunkNumber (1-based; 0 selects
+// the last) of the chunk with the given FourCC, e.g. "ICCP", "EXIF" or
+// "XMP ". It returns false if there is no such chunk. A successful iter must
+// be released with WebPDemuxReleaseChunkIterator.
+func WebPDemuxGetChunk(dmux uintptr, fourcc string, chunkNumber int, iter *ChunkIterator) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	tag, err := fourccTag(fourcc)
+	if err != nil {
+		return false, err
+	}
+	if dmux == 0 || iter == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxGetChunk(dmux, &tag[0], int32(chunkNumber), iter) != 0, nil
+}
+
+// WebPDemuxNextChunk advances iter to the next chunk with the same FourCC,
+// returning false at the end.
+func WebPDemuxNextChunk(iter *ChunkIterator) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if iter == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxNextChunk(iter) != 0, nil
+}
+
+// WebPDemuxPrevChunk moves iter to the previous chunk with the same FourCC,
+// returning false at the start.
+func WebPDemuxPrevChunk(iter *ChunkIterator) (ok bool, err error) {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return false, err
+	}
+	if iter == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPDemuxPrevChunk(iter) != 0, nil
+}
+
+// WebPDemuxReleaseChunkIterator releases the memory held by iter.
+func WebPDemuxReleaseChunkIterator(iter *ChunkIterator) error {
+	if err := lowlevel.EnsureDemuxLoaded(); err != nil {
+		return err
+	}
+	if iter == nil {
+		return nil
+	}
+
+	lowlevel.WebPDemuxReleaseChunkIterator(iter)
+	return nil
+}
+
+// ChunkIteratorBytes copies the payload of the chunk iter points at.
+func ChunkIteratorBytes(iter *ChunkIterator) []byte {
+	if iter == nil {
+		return nil
+	}
+	return append([]byte(nil), cBytes(iter.Chunk.Bytes, int(iter.Chunk.Size))...)
+}
+
+// fourccTag returns fourcc as the NUL-terminated tag libwebp expects.
+func fourccTag(fourcc string) (*[5]byte, error) {
+	if len(fourcc) != 4 {
+		return nil, ErrInvalidData
+	}
+	var tag [5]byte
+	copy(tag[:], fourcc)
+	return &tag, nil
+}
@@ -0,0 +1,107 @@
+package libwebp
+
+import (
+	"errors"
+	"fmt"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// ErrVersionTooOld indicates the loaded libwebp is older than required.
+var ErrVersionTooOld = errors.New("libwebp: loaded library version too old")
+
+// Encoder config features that are silently ignored by libwebp releases
+// older than the one that introduced them. Names match the WebPConfig fields.
+const (
+	FeatureNearLossless    = "near_lossless"
+	FeatureExact           = "exact"
+	FeatureUseDeltaPalette = "use_delta_palette"
+	FeatureUseSharpYuv     = "use_sharp_yuv"
+	FeatureQMin            = "qmin"
+	FeatureQMax            = "qmax"
+)
+
+// featureMinVersions maps each feature to the first encoder version
+// supporting it, packed as 0xMMmmpp like Version.
+var featureMinVersions = map[string]uint32{
+	FeatureNearLossless:    0x000500,
+	FeatureExact:           0x000500,
+	FeatureUseDeltaPalette: 0x000500,
+	FeatureUseSharpYuv:     0x000600,
+	FeatureQMin:            0x010200,
+	FeatureQMax:            0x010200,
+}
+
+// FeatureMinVersion returns the first libwebp encoder version (packed
+// 0xMMmmpp) that honors feature, and false for unknown features.
+func FeatureMinVersion(feature string) (uint32, bool) {
+	v, ok := featureMinVersions[feature]
+	return v, ok
+}
+
+// FeatureSupported reports whether the loaded libwebp encoder honors feature.
+func FeatureSupported(feature string) (bool, error) {
+	minVersion, ok := FeatureMinVersion(feature)
+	if !ok {
+		return false, fmt.Errorf("unknown libwebp feature %q", feature)
+	}
+	_, encoder, err := Version()
+	if err != nil {
+		return false, err
+	}
+	return encoder >= minVersion, nil
+}
+
+// FormatVersion formats a packed 0xMMmmpp version as "major.minor.patch".
+func FormatVersion(v uint32) string {
+	return fmt.Sprintf("%d.%d.%d", v>>16, v>>8&0xff, v&0xff)
+}
+
+// DecoderVersionString returns the loaded decoder version as
+// "major.minor.patch", e.g. "1.3.2".
+func DecoderVersionString() (string, error) {
+	decoder, _, err := Version()
+	if err != nil {
+		return "", err
+	}
+	return FormatVersion(decoder), nil
+}
+
+// EncoderVersionString returns the loaded encoder version as
+// "major.minor.patch".
+func EncoderVersionString() (string, error) {
+	_, encoder, err := Version()
+	if err != nil {
+		return "", err
+	}
+	return FormatVersion(encoder), nil
+}
+
+// MissingOptionalSymbols loads libwebp and returns the names of optional
+// functions it does not export, such as WebPValidateDecoderConfig on
+// releases before 1.6.0. Wrappers for these return ErrNotAvailable. The
+// result is nil when everything resolved or the library cannot be loaded.
+func MissingOptionalSymbols() []string {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return nil
+	}
+	return lowlevel.MissingOptionalSymbols()
+}
+
+// RequireVersion loads libwebp and checks that its decoder and encoder
+// versions, packed as 0xMMmmpp like Version (e.g. 0x010200 for 1.2.0), are at
+// least minDecoder and minEncoder. The error wraps ErrVersionTooOld and names
+// the versions found. Zero minimums always pass once the library loads.
+func RequireVersion(minDecoder, minEncoder uint32) error {
+	decoder, encoder, err := Version()
+	if err != nil {
+		return err
+	}
+	if decoder < minDecoder {
+		return fmt.Errorf("%w: decoder %s, need %s", ErrVersionTooOld, FormatVersion(decoder), FormatVersion(minDecoder))
+	}
+	if encoder < minEncoder {
+		return fmt.Errorf("%w: encoder %s, need %s", ErrVersionTooOld, FormatVersion(encoder), FormatVersion(minEncoder))
+	}
+	return nil
+}
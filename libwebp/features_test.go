@@ -0,0 +1,121 @@
+package libwebp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFeatureMinVersion(t *testing.T) {
+	if v, ok := FeatureMinVersion(FeatureUseSharpYuv); !ok || FormatVersion(v) != "0.6.0" {
+		t.Fatalf("FeatureMinVersion(%q) = (%#x, %v), want 0.6.0", FeatureUseSharpYuv, v, ok)
+	}
+	if _, ok := FeatureMinVersion("no_such_feature"); ok {
+		t.Fatal("FeatureMinVersion(unknown) ok = true")
+	}
+	if _, err := FeatureSupported("no_such_feature"); err == nil {
+		t.Fatal("FeatureSupported(unknown) error = nil")
+	}
+
+	_, encoder, err := Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	supported, err := FeatureSupported(FeatureQMin)
+	if err != nil {
+		t.Fatalf("FeatureSupported() error = %v", err)
+	}
+	if want := encoder >= 0x010200; supported != want {
+		t.Fatalf("FeatureSupported(%q) = %v with encoder %s, want %v", FeatureQMin, supported, FormatVersion(encoder), want)
+	}
+}
+
+func TestVersionStrings(t *testing.T) {
+	if got := FormatVersion(0x010302); got != "1.3.2" {
+		t.Fatalf("FormatVersion(0x010302) = %q, want %q", got, "1.3.2")
+	}
+	decoder, encoder, err := Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	for name, tt := range map[string]struct {
+		fn     func() (string, error)
+		packed uint32
+	}{
+		"DecoderVersionString": {DecoderVersionString, decoder},
+		"EncoderVersionString": {EncoderVersionString, encoder},
+	} {
+		got, err := tt.fn()
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		var major, minor, patch uint32
+		if n, _ := fmt.Sscanf(got, "%d.%d.%d", &major, &minor, &patch); n != 3 || major<<16|minor<<8|patch != tt.packed {
+			t.Fatalf("%s() = %q, want the packed %#06x", name, got, tt.packed)
+		}
+		if major == 0 && minor < 5 {
+			t.Fatalf("%s() = %q, implausibly old", name, got)
+		}
+	}
+}
+
+func TestRequireVersion(t *testing.T) {
+	if err := RequireVersion(0, 0); err != nil {
+		t.Fatalf("RequireVersion(0, 0) error = %v", err)
+	}
+	decoder, encoder, err := Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if err := RequireVersion(decoder, encoder); err != nil {
+		t.Fatalf("RequireVersion(current) error = %v", err)
+	}
+	if err := RequireVersion(0xff0000, 0); !errors.Is(err, ErrVersionTooOld) {
+		t.Fatalf("RequireVersion(255.0.0, 0) error = %v, want %v", err, ErrVersionTooOld)
+	}
+	if err := RequireVersion(0, 0xff0000); !errors.Is(err, ErrVersionTooOld) {
+		t.Fatalf("RequireVersion(0, 255.0.0) error = %v, want %v", err, ErrVersionTooOld)
+	}
+}
+
+func TestMissingOptionalSymbols(t *testing.T) {
+	missing := MissingOptionalSymbols()
+	if got, want := slices.Contains(missing, "WebPValidateDecoderConfig"), !WebPValidateDecoderConfigAvailable(); got != want {
+		t.Fatalf("MissingOptionalSymbols() = %q, inconsistent with WebPValidateDecoderConfigAvailable()", missing)
+	}
+
+	decoder, _, err := Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if decoder >= 0x010600 && len(missing) != 0 {
+		t.Fatalf("MissingOptionalSymbols() = %q on libwebp %s, want none", missing, FormatVersion(decoder))
+	}
+}
+
+func TestWebPGetFeaturesFormat(t *testing.T) {
+	pix := make([]byte, 8*8*4)
+	lossy, err := WebPEncodeRGBA(pix, 8, 8, 8*4, 75)
+	if err != nil {
+		t.Fatalf("WebPEncodeRGBA() error = %v", err)
+	}
+	for _, tt := range []struct {
+		data []byte
+		want BitstreamFormat
+	}{
+		{lossy, FormatLossy},
+		{encodeTestRGBA(t, 8, 8), FormatLossless},
+	} {
+		features, status, err := WebPGetFeatures(tt.data)
+		if err != nil || status != VP8StatusOK {
+			t.Fatalf("WebPGetFeatures() = (%v, %v)", status, err)
+		}
+		if features.Format != tt.want {
+			t.Errorf("Format = %v, want %v", features.Format, tt.want)
+		}
+	}
+	if got := BitstreamFormat(7).String(); got != "BitstreamFormat(7)" {
+		t.Errorf("BitstreamFormat(7).String() = %q", got)
+	}
+}
@@ -5,9 +5,11 @@
 package libwebp
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
+	"runtime"
 	"unsafe"
 
 	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
@@ -29,6 +31,17 @@ var (
 	// ErrNotAvailable indicates the function is not available in the loaded
 	// libwebp version. Use the corresponding Available() helper to check first.
 	ErrNotAvailable = errors.New("libwebp: function not available in loaded library version")
+	// ErrInvalidEnum indicates an enum argument, such as a Preset, outside
+	// the values libwebp defines.
+	ErrInvalidEnum = errors.New("libwebp: enum value out of range")
+	// ErrInvalidConfig indicates an encoder config rejected by
+	// WebPValidateConfig.
+	ErrInvalidConfig = errors.New("libwebp: encoder config failed validation")
+	// ErrInvalidDecoderConfig indicates a decoder config rejected by
+	// WebPValidateDecoderConfig.
+	ErrInvalidDecoderConfig = errors.New("libwebp: decoder config failed validation")
+	// ErrAlreadyLoaded indicates Load was called after libwebp was loaded.
+	ErrAlreadyLoaded = lowlevel.ErrAlreadyLoaded
 )
 
 // VP8StatusCode is the status enum used by libwebp decode APIs.
@@ -45,12 +58,60 @@ const (
 	VP8StatusNotEnoughData   VP8StatusCode = 7
 )
 
+func (s VP8StatusCode) String() string {
+	switch s {
+	case VP8StatusOK:
+		return "OK"
+	case VP8StatusOutOfMemory:
+		return "OutOfMemory"
+	case VP8StatusInvalidParam:
+		return "InvalidParam"
+	case VP8StatusBitstreamError:
+		return "BitstreamError"
+	case VP8StatusUnsupportedFeat:
+		return "UnsupportedFeature"
+	case VP8StatusSuspended:
+		return "Suspended"
+	case VP8StatusUserAbort:
+		return "UserAbort"
+	case VP8StatusNotEnoughData:
+		return "NotEnoughData"
+	default:
+		return fmt.Sprintf("VP8StatusCode(%d)", int32(s))
+	}
+}
+
+// BitstreamFormat is the compression of a WebP bitstream as reported by
+// WebPGetFeatures.
+type BitstreamFormat int
+
+const (
+	// FormatUndefined is reported for animations and files mixing lossy and
+	// lossless frames.
+	FormatUndefined BitstreamFormat = 0
+	FormatLossy     BitstreamFormat = 1
+	FormatLossless  BitstreamFormat = 2
+)
+
+func (f BitstreamFormat) String() string {
+	switch f {
+	case FormatUndefined:
+		return "Undefined"
+	case FormatLossy:
+		return "Lossy"
+	case FormatLossless:
+		return "Lossless"
+	default:
+		return fmt.Sprintf("BitstreamFormat(%d)", int(f))
+	}
+}
+
 type BitstreamFeatures struct {
 	Width        int
 	Height       int
 	HasAlpha     bool
 	HasAnimation bool
-	Format       int
+	Format       BitstreamFormat
 }
 
 // DecBuffer is the low-level decode output buffer struct from libwebp.
@@ -71,36 +132,79 @@ type MemoryWriter = lowlevel.WebPMemoryWriter
 // Picture is the low-level picture struct used by advanced encode APIs.
 type Picture = lowlevel.WebPPicture
 
+// AuxStats is the low-level post-encode statistics struct (WebPAuxStats).
+// PSNR holds the Y, U, V, alpha and overall values in that order.
+type AuxStats = lowlevel.WebPAuxStats
+
+// Preset is the encoder preset enum (WebPPreset) used by WebPConfigPreset.
+type Preset int32
+
 const (
 	// Encoder presets used by WebPConfigPreset.
-	PresetDefault = 0
-	PresetPicture = 1
-	PresetPhoto   = 2
-	PresetDrawing = 3
-	PresetIcon    = 4
-	PresetText    = 5
-
-	HintDefault = 0
-	HintPicture = 1
-	HintPhoto   = 2
-	HintGraph   = 3
-	HintLast    = 4
+	PresetDefault Preset = 0
+	PresetPicture Preset = 1
+	PresetPhoto   Preset = 2
+	PresetDrawing Preset = 3
+	PresetIcon    Preset = 4
+	PresetText    Preset = 5
+)
 
+// Valid reports whether p is one of the libwebp presets.
+func (p Preset) Valid() bool {
+	return p >= PresetDefault && p <= PresetText
+}
+
+// Hint is the image type hint enum (WebPImageHint) stored in
+// Config.ImageHint, used by the lossless encoder.
+type Hint int32
+
+const (
+	HintDefault Hint = 0
+	HintPicture Hint = 1
+	HintPhoto   Hint = 2
+	HintGraph   Hint = 3
+	HintLast    Hint = 4
+)
+
+// Valid reports whether h is one of the libwebp hints; HintLast only marks
+// the end of the enum and is not valid.
+func (h Hint) Valid() bool {
+	return h >= HintDefault && h < HintLast
+}
+
+// ColorspaceMode is the decode output colorspace enum (WEBP_CSP_MODE).
+type ColorspaceMode int32
+
+const (
 	// Decode output colorspace/mode constants from decode.h.
-	ModeRGB      = 0
-	ModeRGBA     = 1
-	ModeBGR      = 2
-	ModeBGRA     = 3
-	ModeARGB     = 4
-	ModeRGBA4444 = 5
-	ModeRGB565   = 6
-	ModergbA     = 7
-	ModebgrA     = 8
-	ModeArgb     = 9
-	ModergbA4444 = 10
-	ModeYUV      = 11
-	ModeYUVA     = 12
-	ModeLast     = 13
+	ModeRGB      ColorspaceMode = 0
+	ModeRGBA     ColorspaceMode = 1
+	ModeBGR      ColorspaceMode = 2
+	ModeBGRA     ColorspaceMode = 3
+	ModeARGB     ColorspaceMode = 4
+	ModeRGBA4444 ColorspaceMode = 5
+	ModeRGB565   ColorspaceMode = 6
+	ModergbA     ColorspaceMode = 7
+	ModebgrA     ColorspaceMode = 8
+	ModeArgb     ColorspaceMode = 9
+	ModergbA4444 ColorspaceMode = 10
+	ModeYUV      ColorspaceMode = 11
+	ModeYUVA     ColorspaceMode = 12
+	ModeLast     ColorspaceMode = 13
+)
+
+// EncCSP is the picture colorspace enum (WebPEncCSP) used by the encoder.
+type EncCSP int32
+
+const (
+	// CSPYUV420 is 4:2:0 YUV without alpha.
+	CSPYUV420 EncCSP = 0
+	// CSPYUV420A is 4:2:0 YUV with an alpha plane.
+	CSPYUV420A EncCSP = 4
+	// CSPUVMask masks the chroma sampling bits.
+	CSPUVMask EncCSP = 3
+	// CSPAlphaBit is set when the picture carries alpha.
+	CSPAlphaBit EncCSP = 4
 )
 
 // Available reports whether libwebp can be loaded in the current environment.
@@ -108,6 +212,68 @@ func Available() bool {
 	return lowlevel.Available()
 }
 
+// SetLibraryPath makes the loader open the libwebp shared library at path
+// instead of searching the default names, for libraries installed in
+// non-standard locations. A file path replaces discovery rather than being tried
+// first: neither WEBP_LIBRARY_PATH nor the default names are tried after it,
+// so if path fails to load, every call reports that error. It must be called
+// before the first use of the package.
+//
+// On Windows, path may instead be the directory holding libwebp.dll, for
+// DLLs shipped in an application directory that is not on PATH: each
+// default name is then tried in that directory first, and it is made the DLL
+// directory so that libwebp's own dependencies resolve there too.
+//
+// Without an explicit path, the WEBP_LIBRARY_PATH environment variable, if
+// set, is tried before the default names, which are still tried when it
+// fails to load.
+func SetLibraryPath(path string) {
+	lowlevel.SetLibraryPath(path)
+}
+
+// Load opens the libwebp shared library at path, for example one shipped next
+// to the binary, instead of searching for it. It must be called before any
+// other use of the package and only once; later calls, or calls after the
+// library was loaded on demand, return ErrAlreadyLoaded.
+func Load(path string) error {
+	return lowlevel.Load(path)
+}
+
+// Unload closes libwebp (and libwebpdemux/libwebpmux if they were loaded)
+// and resets the loader, so that the next call loads the library again,
+// honoring SetLibraryPath and Load as if the package were fresh. It exists
+// mainly for tests. It must not be called concurrently with any other use of
+// the package, nor while decoders, encoders, muxers or pictures created by
+// libwebp are still alive. On platforms where unloading is not safe, such as
+// Android, the library stays mapped and only the loader state is reset.
+func Unload() error {
+	return lowlevel.Unload()
+}
+
+// LibraryHandle loads libwebp and returns the handle it was opened with, for
+// advanced callers resolving extra symbols themselves, for example with
+// purego.Dlsym on a patched build. This is unsafe territory: the package
+// cannot check how such symbols are called, and the handle is invalidated by
+// Unload. Do not close it.
+func LibraryHandle() (uintptr, error) {
+	return lowlevel.Handle()
+}
+
+// Preload loads libwebp now instead of on first use, so servers can fail at
+// startup rather than on the first decode. It is optional: every function
+// loads the library on demand.
+func Preload() error {
+	return lowlevel.EnsureLoaded()
+}
+
+// MustLoad is like Preload but panics if libwebp cannot be loaded. The panic
+// value is the load error, which joins the attempt for each candidate name.
+func MustLoad() {
+	if err := Preload(); err != nil {
+		panic(err)
+	}
+}
+
 // Version returns decoder and encoder library versions (packed hex format).
 func Version() (decoder uint32, encoder uint32, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
@@ -148,7 +314,7 @@ func WebPGetFeatures(data []byte) (features BitstreamFeatures, status VP8StatusC
 		Height:       int(raw.Height),
 		HasAlpha:     raw.HasAlpha != 0,
 		HasAnimation: raw.HasAnimation != 0,
-		Format:       int(raw.Format),
+		Format:       BitstreamFormat(raw.Format),
 	}, status, nil
 }
 
@@ -193,7 +359,7 @@ func WebPInitDecoderConfig(config *DecoderConfig) (ok bool, err error) {
 // is available in the loaded libwebp. The symbol was added in libwebp 1.6.0
 // (released 2025-03); most distributions still ship an earlier version.
 func WebPValidateDecoderConfigAvailable() bool {
-	return lowlevel.ValidateDecoderConfigAvailable()
+	return lowlevel.WebPValidateDecoderConfigAvailable()
 }
 
 // WebPValidateDecoderConfig validates decoder config values.
@@ -202,7 +368,7 @@ func WebPValidateDecoderConfig(config *DecoderConfig) (ok bool, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
 		return false, err
 	}
-	if !lowlevel.ValidateDecoderConfigAvailable() {
+	if !lowlevel.WebPValidateDecoderConfigAvailable() {
 		return false, ErrNotAvailable
 	}
 	if config == nil {
@@ -212,6 +378,19 @@ func WebPValidateDecoderConfig(config *DecoderConfig) (ok bool, err error) {
 	return lowlevel.WebPValidateDecoderConfig(config) != 0, nil
 }
 
+// ValidateDecoderConfig is WebPValidateDecoderConfig reporting an invalid
+// config as ErrInvalidDecoderConfig.
+func ValidateDecoderConfig(config *DecoderConfig) error {
+	ok, err := WebPValidateDecoderConfig(config)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidDecoderConfig
+	}
+	return nil
+}
+
 // WebPConfigInit initializes encoder config to default preset/quality.
 func WebPConfigInit(config *Config) (ok bool, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
@@ -221,19 +400,23 @@ func WebPConfigInit(config *Config) (ok bool, err error) {
 		return false, ErrInvalidData
 	}
 
-	return lowlevel.WebPConfigInitInternal(config, PresetDefault, 75, lowlevel.WebPEncoderABIVersion) != 0, nil
+	return lowlevel.WebPConfigInitInternal(config, int32(PresetDefault), 75, lowlevel.WebPEncoderABIVersion) != 0, nil
 }
 
-// WebPConfigPreset initializes encoder config with the given preset and quality.
-func WebPConfigPreset(config *Config, preset int32, quality float32) (ok bool, err error) {
+// WebPConfigPreset initializes encoder config with the given preset and
+// quality. A preset outside the defined values returns ErrInvalidEnum.
+func WebPConfigPreset(config *Config, preset Preset, quality float32) (ok bool, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
 		return false, err
 	}
 	if config == nil {
 		return false, ErrInvalidData
 	}
+	if !preset.Valid() {
+		return false, fmt.Errorf("%w: preset %d", ErrInvalidEnum, preset)
+	}
 
-	return lowlevel.WebPConfigInitInternal(config, preset, quality, lowlevel.WebPEncoderABIVersion) != 0, nil
+	return lowlevel.WebPConfigInitInternal(config, int32(preset), quality, lowlevel.WebPEncoderABIVersion) != 0, nil
 }
 
 // WebPConfigLosslessPreset applies a built-in lossless level preset.
@@ -260,6 +443,45 @@ func WebPValidateConfig(config *Config) (ok bool, err error) {
 	return lowlevel.WebPValidateConfig(config) != 0, nil
 }
 
+// ValidateConfig is WebPValidateConfig reporting an invalid config as
+// ErrInvalidConfig.
+func ValidateConfig(config *Config) error {
+	ok, err := WebPValidateConfig(config)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+// CloneConfig returns a copy of src, or nil if src is nil. WebPConfig holds
+// only scalar fields, so the copy is fully independent: a validated base
+// config can be cloned and tweaked per image.
+func CloneConfig(src *Config) *Config {
+	if src == nil {
+		return nil
+	}
+	clone := *src
+	return &clone
+}
+
+// CloneDecoderConfig returns a copy of src's input features, options and
+// output colorspace, or nil if src is nil. The output buffer is not carried
+// over: the clone starts with an empty one, so decoding with it never aliases
+// or double-frees memory owned by src.
+func CloneDecoderConfig(src *DecoderConfig) *DecoderConfig {
+	if src == nil {
+		return nil
+	}
+	return &DecoderConfig{
+		Input:   src.Input,
+		Options: src.Options,
+		Output:  DecBuffer{Colorspace: src.Output.Colorspace},
+	}
+}
+
 // WebPPictureInit initializes a picture struct with ABI-checked defaults.
 func WebPPictureInit(picture *Picture) (ok bool, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
@@ -272,6 +494,251 @@ func WebPPictureInit(picture *Picture) (ok bool, err error) {
 	return lowlevel.WebPPictureInitInternal(picture, lowlevel.WebPEncoderABIVersion) != 0, nil
 }
 
+// WebPPictureAlloc allocates the pixel planes of picture according to its
+// dimensions and UseArgb setting.
+func WebPPictureAlloc(picture *Picture) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPPictureAlloc(picture) != 0, nil
+}
+
+// WebPPictureFree releases memory owned by picture.
+func WebPPictureFree(picture *Picture) error {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return err
+	}
+	if picture == nil {
+		return nil
+	}
+
+	lowlevel.WebPPictureFree(picture)
+	return nil
+}
+
+// WebPPictureImportRGBA imports packed RGBA pixels into picture.
+// Picture width and height must be set before calling.
+func WebPPictureImportRGBA(picture *Picture, rgba []byte, stride int) (ok bool, err error) {
+	return importPicture(picture, rgba, stride, 4, lowlevel.WebPPictureImportRGBA)
+}
+
+// WebPPictureImportRGBX imports packed RGBX pixels into picture, ignoring X.
+func WebPPictureImportRGBX(picture *Picture, rgbx []byte, stride int) (ok bool, err error) {
+	return importPicture(picture, rgbx, stride, 4, lowlevel.WebPPictureImportRGBX)
+}
+
+// WebPPictureImportRGB imports packed RGB pixels into picture.
+func WebPPictureImportRGB(picture *Picture, rgb []byte, stride int) (ok bool, err error) {
+	return importPicture(picture, rgb, stride, 3, lowlevel.WebPPictureImportRGB)
+}
+
+// WebPPictureImportBGRA imports packed BGRA pixels into picture.
+func WebPPictureImportBGRA(picture *Picture, bgra []byte, stride int) (ok bool, err error) {
+	return importPicture(picture, bgra, stride, 4, lowlevel.WebPPictureImportBGRA)
+}
+
+// WebPPictureImportBGRX imports packed BGRX pixels into picture, ignoring X.
+func WebPPictureImportBGRX(picture *Picture, bgrx []byte, stride int) (ok bool, err error) {
+	return importPicture(picture, bgrx, stride, 4, lowlevel.WebPPictureImportBGRX)
+}
+
+// WebPPictureImportBGR imports packed BGR pixels into picture.
+func WebPPictureImportBGR(picture *Picture, bgr []byte, stride int) (ok bool, err error) {
+	return importPicture(picture, bgr, stride, 3, lowlevel.WebPPictureImportBGR)
+}
+
+// WebPPictureARGBToYUVA converts the ARGB samples of picture to YUV(A) using
+// the given colorspace. Use CSPYUV420A to keep the alpha plane.
+func WebPPictureARGBToYUVA(picture *Picture, colorspace EncCSP) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPPictureARGBToYUVA(picture, int32(colorspace)) != 0, nil
+}
+
+// WebPPictureSharpARGBToYUVA converts the ARGB samples of picture to YUV
+// using the slower "sharp" RGB->YUV conversion, which preserves chroma edges.
+func WebPPictureSharpARGBToYUVA(picture *Picture) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPPictureSharpARGBToYUVA(picture) != 0, nil
+}
+
+// WebPPictureSmartARGBToYUVA is the deprecated libwebp alias of
+// WebPPictureSharpARGBToYUVA.
+func WebPPictureSmartARGBToYUVA(picture *Picture) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPPictureSmartARGBToYUVA(picture) != 0, nil
+}
+
+// WebPPictureYUVAToARGB converts the YUV(A) samples of picture to ARGB.
+func WebPPictureYUVAToARGB(picture *Picture) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPPictureYUVAToARGB(picture) != 0, nil
+}
+
+// WebPPictureHasTransparency reports whether picture contains any
+// non-opaque pixel.
+func WebPPictureHasTransparency(picture *Picture) (bool, error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+
+	return lowlevel.WebPPictureHasTransparency(picture) != 0, nil
+}
+
+// WebPCleanupTransparentArea replaces the RGB values of fully transparent
+// areas of picture with flat values that compress better. It has no visible
+// effect but discards the hidden colors.
+func WebPCleanupTransparentArea(picture *Picture) error {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return err
+	}
+	if picture == nil {
+		return ErrInvalidData
+	}
+
+	lowlevel.WebPCleanupTransparentArea(picture)
+	return nil
+}
+
+// WebPBlendAlpha composites picture onto the opaque background color
+// backgroundRGB (0xRRGGBB) and makes every pixel fully opaque.
+func WebPBlendAlpha(picture *Picture, backgroundRGB uint32) error {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return err
+	}
+	if picture == nil {
+		return ErrInvalidData
+	}
+
+	lowlevel.WebPBlendAlpha(picture, backgroundRGB)
+	return nil
+}
+
+// PictureARGBToRGBA copies the ARGB samples of picture into dst as packed
+// RGBA rows of the given stride. The picture must use ARGB (UseArgb != 0).
+// The copy is done in Go; libwebp has no such export.
+func PictureARGBToRGBA(picture *Picture, dst []byte, stride int) error {
+	if picture == nil || picture.UseArgb == 0 || picture.Argb == 0 {
+		return ErrInvalidData
+	}
+	width, height := int(picture.Width), int(picture.Height)
+	if err := validatePixelInput(dst, width, height, stride, 4); err != nil {
+		return err
+	}
+
+	argbStride := int(picture.ArgbStride)
+	src := unsafe.Slice(*(**uint32)(unsafe.Pointer(&picture.Argb)), argbStride*(height-1)+width)
+	for y := range height {
+		row := dst[y*stride : y*stride+width*4]
+		for x, p := range src[y*argbStride : y*argbStride+width] {
+			row[x*4+0] = uint8(p >> 16)
+			row[x*4+1] = uint8(p >> 8)
+			row[x*4+2] = uint8(p)
+			row[x*4+3] = uint8(p >> 24)
+		}
+	}
+	return nil
+}
+
+// WebPPictureImportYUV420 imports 4:2:0 Y, U and V planes into picture,
+// which becomes a YUV picture (UseArgb = 0, CSPYUV420). The samples must be
+// in libwebp's BT.601 limited range; the chroma planes are (Width+1)/2 by
+// (Height+1)/2. Picture width and height must be set before calling. The
+// planes are copied into memory owned by picture.
+func WebPPictureImportYUV420(picture *Picture, y, u, v []byte, yStride, uvStride int) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+	width, height := int(picture.Width), int(picture.Height)
+	uvWidth, uvHeight := (width+1)/2, (height+1)/2
+	if err := validatePixelInput(y, width, height, yStride, 1); err != nil {
+		return false, err
+	}
+	for _, plane := range [][]byte{u, v} {
+		if err := validatePixelInput(plane, uvWidth, uvHeight, uvStride, 1); err != nil {
+			return false, err
+		}
+	}
+
+	picture.UseArgb = 0
+	picture.Colorspace = int32(CSPYUV420)
+	if lowlevel.WebPPictureAlloc(picture) == 0 {
+		return false, nil
+	}
+	copyPlane(picture.Y, int(picture.YStride), y, yStride, width, height)
+	copyPlane(picture.U, int(picture.UvStride), u, uvStride, uvWidth, uvHeight)
+	copyPlane(picture.V, int(picture.UvStride), v, uvStride, uvWidth, uvHeight)
+	return true, nil
+}
+
+// copyPlane copies width by height samples from src into the libwebp-owned
+// plane at dst.
+func copyPlane(dst uintptr, dstStride int, src []byte, srcStride, width, height int) {
+	plane := unsafe.Slice(*(**byte)(unsafe.Pointer(&dst)), dstStride*(height-1)+width)
+	for row := range height {
+		copy(plane[row*dstStride:row*dstStride+width], src[row*srcStride:row*srcStride+width])
+	}
+}
+
+// AttachPictureStats points picture.Stats at stats so that WebPEncode fills
+// it in. stats stays pinned until the returned release func is called, which
+// also detaches it from picture.
+func AttachPictureStats(picture *Picture, stats *AuxStats) (release func()) {
+	if picture == nil || stats == nil {
+		return func() {}
+	}
+
+	pinner := new(runtime.Pinner)
+	pinner.Pin(stats)
+	picture.Stats = uintptr(unsafe.Pointer(stats))
+	return func() {
+		picture.Stats = 0
+		pinner.Unpin()
+	}
+}
+
+// GetPictureStats returns a copy of the statistics attached to picture with
+// AttachPictureStats, or the zero value if none are attached.
+func GetPictureStats(picture *Picture) AuxStats {
+	if picture == nil || picture.Stats == 0 {
+		return AuxStats{}
+	}
+	return **(**AuxStats)(unsafe.Pointer(&picture.Stats))
+}
+
 // WebPMemoryWriterInit initializes a memory writer instance.
 func WebPMemoryWriterInit(writer *MemoryWriter) error {
 	if err := lowlevel.EnsureLoaded(); err != nil {
@@ -299,6 +766,7 @@ func WebPMemoryWriterClear(writer *MemoryWriter) error {
 }
 
 // WebPEncode runs advanced encoding with explicit config and picture structs.
+// On failure the error is an *EncodeError carrying picture.ErrorCode.
 func WebPEncode(config *Config, picture *Picture) (ok bool, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
 		return false, err
@@ -307,7 +775,93 @@ func WebPEncode(config *Config, picture *Picture) (ok bool, err error) {
 		return false, ErrInvalidData
 	}
 
-	return lowlevel.WebPEncode(config, picture) != 0, nil
+	if lowlevel.WebPEncode(config, picture) == 0 {
+		return false, encodeErrorFrom(picture)
+	}
+	return true, nil
+}
+
+// WebPEncodeMemory runs WebPEncode with a libwebp memory writer attached to
+// picture and returns the encoded bytes as an owned Go buffer. The writer is
+// detached from picture and its memory released before returning.
+func WebPEncodeMemory(config *Config, picture *Picture) ([]byte, error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return nil, err
+	}
+	if config == nil || picture == nil {
+		return nil, ErrInvalidData
+	}
+	write, err := lowlevel.SymbolAddress("WebPMemoryWrite")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := new(MemoryWriter)
+	lowlevel.WebPMemoryWriterInit(writer)
+	defer lowlevel.WebPMemoryWriterClear(writer)
+
+	var pinner runtime.Pinner
+	pinner.Pin(writer)
+	defer pinner.Unpin()
+
+	prevWriter, prevCustomPtr := picture.Writer, picture.CustomPtr
+	picture.Writer = write
+	picture.CustomPtr = uintptr(unsafe.Pointer(writer))
+	defer func() {
+		picture.Writer, picture.CustomPtr = prevWriter, prevCustomPtr
+	}()
+
+	if lowlevel.WebPEncode(config, picture) == 0 {
+		return nil, encodeErrorFrom(picture)
+	}
+
+	b := make([]byte, int(writer.Size))
+	copy(b, cBytes(writer.Mem, len(b)))
+	return b, nil
+}
+
+// EncodePicture encodes packed RGB (bpp 3) or RGBA (bpp 4) pixels with
+// config in one call: it initializes a Picture, imports pix, encodes it to
+// memory and frees the picture, returning an owned copy of the bitstream.
+// Any other bpp returns ErrInvalidData.
+func EncodePicture(config *Config, pix []byte, width, height, stride, bpp int) ([]byte, error) {
+	if config == nil {
+		return nil, ErrInvalidData
+	}
+	var importFn func(*Picture, []byte, int) (bool, error)
+	switch bpp {
+	case 3:
+		importFn = WebPPictureImportRGB
+	case 4:
+		importFn = WebPPictureImportRGBA
+	default:
+		return nil, ErrInvalidData
+	}
+	if width <= 0 || height <= 0 {
+		return nil, ErrInvalidDimension
+	}
+
+	var picture Picture
+	ok, err := WebPPictureInit(&picture)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrEncodeFailed
+	}
+	defer lowlevel.WebPPictureFree(&picture)
+	picture.Width, picture.Height = int32(width), int32(height)
+	// Like the shortcut encoders, keep ARGB samples only for lossless.
+	picture.UseArgb = config.Lossless
+	ok, err = importFn(&picture, pix, stride)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrEncodeFailed
+	}
+
+	return WebPEncodeMemory(config, &picture)
 }
 
 // WebPINewDecoder creates an incremental decoder using the provided output buffer.
@@ -325,13 +879,13 @@ func WebPINewDecoder(outputBuffer *DecBuffer) (uintptr, error) {
 }
 
 // WebPINewRGB creates an incremental decoder producing packed RGB-family output.
-func WebPINewRGB(csp int32, outputBuffer []byte, outputStride int32) (uintptr, error) {
+func WebPINewRGB(csp ColorspaceMode, outputBuffer []byte, outputStride int32) (uintptr, error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
 		return 0, err
 	}
 
 	ptr, size := ptrAndSize(outputBuffer)
-	idec := lowlevel.WebPINewRGB(csp, ptr, size, outputStride)
+	idec := lowlevel.WebPINewRGB(int32(csp), ptr, size, outputStride)
 	if idec == 0 {
 		return 0, ErrDecodeFailed
 	}
@@ -414,15 +968,20 @@ func WebPIUpdate(idec uintptr, data []byte) (VP8StatusCode, error) {
 }
 
 // WebPIDecode creates an incremental decoder with optional config and input.
+// data may be empty, in which case all the input arrives through WebPIAppend
+// or WebPIUpdate.
+//
+// libwebp keeps pointers to config.Options and config.Output until
+// WebPIDelete, so a non-nil config must be pinned and kept alive until then;
+// a libwebp-allocated config.Output must be freed afterwards with
+// WebPFreeDecBuffer.
 func WebPIDecode(data []byte, config *DecoderConfig) (uintptr, error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
 		return 0, err
 	}
-	if len(data) == 0 {
-		return 0, ErrInvalidData
-	}
 
-	idec := lowlevel.WebPIDecode(&data[0], uintptr(len(data)), config)
+	ptr, size := ptrAndSize(data)
+	idec := lowlevel.WebPIDecode(ptr, size, config)
 	if idec == 0 {
 		return 0, ErrDecodeFailed
 	}
@@ -502,6 +1061,50 @@ func WebPDecode(data []byte, config *DecoderConfig) (status VP8StatusCode, err e
 	return VP8StatusCode(lowlevel.WebPDecode(&data[0], uintptr(len(data)), config)), nil
 }
 
+// WebPDecodeWithConfig runs WebPDecode with config and returns the packed
+// output as an owned Go buffer. A failed decode returns a *StatusError.
+// config.Output.Colorspace selects the RGB-family output mode; decoder
+// options such as cropping, scaling and flipping are honored. The
+// libwebp-owned output buffer is released before returning.
+func WebPDecodeWithConfig(data []byte, config *DecoderConfig) (pix []byte, width, height, stride int, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if len(data) == 0 || config == nil {
+		return nil, 0, 0, 0, ErrInvalidData
+	}
+	bytesPerPixel := modeBytesPerPixel(ColorspaceMode(config.Output.Colorspace))
+	if bytesPerPixel == 0 {
+		return nil, 0, 0, 0, ErrInvalidData
+	}
+
+	config.Output.IsExternalMemory = 0
+	status := VP8StatusCode(lowlevel.WebPDecode(&data[0], uintptr(len(data)), config))
+	defer lowlevel.WebPFreeDecBuffer(&config.Output)
+	if err := ErrorFromStatus(status); err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	width = int(config.Output.Width)
+	height = int(config.Output.Height)
+	stride, bufLen, err := checkedDecodeLayout(width, height, bytesPerPixel)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	out := config.Output.RGBA()
+	src := cBytes(out.RGBA, int(out.Size))
+	srcStride := int(out.Stride)
+	if srcStride < stride || len(src) < srcStride*(height-1)+stride {
+		return nil, 0, 0, 0, ErrDecodeFailed
+	}
+
+	pix = make([]byte, bufLen)
+	for y := range height {
+		copy(pix[y*stride:(y+1)*stride], src[y*srcStride:])
+	}
+	return pix, width, height, stride, nil
+}
+
 // WebPDecodeRGBA decodes to packed RGBA and returns an owned Go buffer.
 func WebPDecodeRGBA(data []byte) (pix []byte, width, height, stride int, err error) {
 	return decodeToOwnedBuffer(data, 4, lowlevel.WebPDecodeRGBA)
@@ -527,6 +1130,35 @@ func WebPDecodeBGR(data []byte) (pix []byte, width, height, stride int, err erro
 	return decodeToOwnedBuffer(data, 3, lowlevel.WebPDecodeBGR)
 }
 
+// WebPDecodeRGB565 decodes to packed 16-bit RGB565 for framebuffer targets
+// and returns an owned Go buffer with a stride of width*2. Each pixel is
+// stored high byte first: RRRRRGGG then GGGBBBBB. Alpha is dropped.
+func WebPDecodeRGB565(data []byte) (pix []byte, width, height, stride int, err error) {
+	return decodeWithMode(data, ModeRGB565)
+}
+
+// WebPDecodeRGBA4444 decodes to packed 16-bit RGBA4444, with straight
+// alpha, for low-memory GPU targets and returns an owned Go buffer with a
+// stride of width*2. Each pixel is stored as RRRRGGGG then BBBBAAAA.
+func WebPDecodeRGBA4444(data []byte) (pix []byte, width, height, stride int, err error) {
+	return decodeWithMode(data, ModeRGBA4444)
+}
+
+// decodeWithMode decodes data with the default decoder options into the
+// packed output mode, for the modes without a one-call libwebp decoder.
+func decodeWithMode(data []byte, mode ColorspaceMode) (pix []byte, width, height, stride int, err error) {
+	var config DecoderConfig
+	ok, err := WebPInitDecoderConfig(&config)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if !ok {
+		return nil, 0, 0, 0, ErrDecodeFailed
+	}
+	config.Output.Colorspace = int32(mode)
+	return WebPDecodeWithConfig(data, &config)
+}
+
 // WebPDecodeRGBAInto decodes into a caller-provided RGBA buffer.
 func WebPDecodeRGBAInto(data []byte, outputBuffer []byte, outputStride int) (width, height int, err error) {
 	return decodeInto(data, outputBuffer, outputStride, 4, lowlevel.WebPDecodeRGBAInto)
@@ -707,7 +1339,8 @@ func decodeInto(data []byte, outputBuffer []byte, outputStride int, bytesPerPixe
 		return 0, 0, err
 	}
 	if !ok {
-		return 0, 0, ErrInvalidData
+		// Keep ErrInvalidData for callers matching it, adding the status.
+		return 0, 0, fmt.Errorf("%w: %w", ErrInvalidData, decodeFailure(data))
 	}
 	if err := decodeIntoWithInfo(data, outputBuffer, outputStride, w, h, bytesPerPixel, fn); err != nil {
 		return 0, 0, err
@@ -737,11 +1370,39 @@ func decodeIntoWithInfo(data []byte, outputBuffer []byte, outputStride, width, h
 		return ErrBufferTooSmall
 	}
 	if fn(&data[0], uintptr(len(data)), &outputBuffer[0], uintptr(len(outputBuffer)), int32(outputStride)) == nil {
-		return ErrDecodeFailed
+		return decodeFailure(data)
 	}
 	return nil
 }
 
+// decodeFailure returns the error for a failed shortcut decode of data. The
+// shortcut functions do not report why they failed, so the status comes from
+// re-parsing the headers with WebPGetFeatures, which is cheap. Headers that
+// parse fine mean the failure is in the image data: a RIFF container shorter
+// than its declared size reports VP8StatusNotEnoughData, anything else plain
+// ErrDecodeFailed. Decoding again to learn more would double the cost of
+// every bad input.
+func decodeFailure(data []byte) error {
+	var raw lowlevel.WebPBitstreamFeatures
+	status := VP8StatusCode(lowlevel.WebPGetFeaturesInternal(&data[0], uintptr(len(data)), &raw, lowlevel.WebPDecoderABIVersion))
+	if status == VP8StatusOK && riffTruncated(data) {
+		status = VP8StatusNotEnoughData
+	}
+	if err := ErrorFromStatus(status); err != nil {
+		return err
+	}
+	return ErrDecodeFailed
+}
+
+// riffTruncated reports whether data is a RIFF container holding fewer bytes
+// than its header declares.
+func riffTruncated(data []byte) bool {
+	if len(data) < 8 || string(data[:4]) != "RIFF" {
+		return false
+	}
+	return uint64(len(data)) < 8+uint64(binary.LittleEndian.Uint32(data[4:8]))
+}
+
 func decodeToOwnedBuffer(data []byte, bytesPerPixel int, fn decodeFunc) (pix []byte, width, height, stride int, err error) {
 	if err := lowlevel.EnsureLoaded(); err != nil {
 		return nil, 0, 0, 0, err
@@ -753,7 +1414,7 @@ func decodeToOwnedBuffer(data []byte, bytesPerPixel int, fn decodeFunc) (pix []b
 	var w, h int32
 	ptr := fn(&data[0], uintptr(len(data)), &w, &h)
 	if ptr == nil {
-		return nil, 0, 0, 0, ErrDecodeFailed
+		return nil, 0, 0, 0, decodeFailure(data)
 	}
 	defer lowlevel.WebPFree(uintptr(unsafe.Pointer(ptr)))
 
@@ -769,6 +1430,22 @@ func decodeToOwnedBuffer(data []byte, bytesPerPixel int, fn decodeFunc) (pix []b
 	return pix, width, height, stride, nil
 }
 
+type importFunc func(picture *Picture, pix *byte, stride int32) int32
+
+func importPicture(picture *Picture, pix []byte, stride, bytesPerPixel int, fn importFunc) (ok bool, err error) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return false, err
+	}
+	if picture == nil {
+		return false, ErrInvalidData
+	}
+	if err := validatePixelInput(pix, int(picture.Width), int(picture.Height), stride, bytesPerPixel); err != nil {
+		return false, err
+	}
+
+	return fn(picture, &pix[0], int32(stride)) != 0, nil
+}
+
 type encodeLossyFunc func(pix *byte, width int32, height int32, stride int32, quality float32, output **byte) uintptr
 
 func encodeWithQuality(pix []byte, width, height, stride, bytesPerPixel int, quality float32, fn encodeLossyFunc) ([]byte, error) {
@@ -857,21 +1534,73 @@ func checkedProduct(a, b int) (int, bool) {
 	return a * b, true
 }
 
+// OutputStride returns the packed row stride libwebp uses for width pixels in
+// the given RGB-family decode mode, e.g. width*4 for ModeRGBA, width*3 for
+// ModeRGB and width*2 for ModeRGB565. YUV modes have one stride per plane and
+// are rejected with ErrInvalidData.
+func OutputStride(width int, mode ColorspaceMode) (int, error) {
+	bytesPerPixel := modeBytesPerPixel(mode)
+	if bytesPerPixel == 0 {
+		return 0, ErrInvalidData
+	}
+	stride, _, err := checkedDecodeLayout(width, 1, bytesPerPixel)
+	if err != nil {
+		return 0, err
+	}
+	return stride, nil
+}
+
+// modeBytesPerPixel returns the packed pixel size of an RGB-family decode
+// mode, or 0 for YUV and unknown modes.
+func modeBytesPerPixel(mode ColorspaceMode) int {
+	switch mode {
+	case ModeRGB, ModeBGR:
+		return 3
+	case ModeRGBA, ModeBGRA, ModeARGB, ModergbA, ModebgrA, ModeArgb:
+		return 4
+	case ModeRGBA4444, ModeRGB565, ModergbA4444:
+		return 2
+	default:
+		return 0
+	}
+}
+
 // WebPIsPremultipliedMode reports whether the decode colorspace is premultiplied.
-func WebPIsPremultipliedMode(mode int) bool {
+func WebPIsPremultipliedMode(mode ColorspaceMode) bool {
 	return mode == ModergbA || mode == ModebgrA || mode == ModeArgb || mode == ModergbA4444
 }
 
 // WebPIsAlphaMode reports whether the decode colorspace contains alpha.
-func WebPIsAlphaMode(mode int) bool {
+func WebPIsAlphaMode(mode ColorspaceMode) bool {
 	return mode == ModeRGBA || mode == ModeBGRA || mode == ModeARGB || mode == ModeRGBA4444 || mode == ModeYUVA || WebPIsPremultipliedMode(mode)
 }
 
 // WebPIsRGBMode reports whether the decode colorspace is RGB-family.
-func WebPIsRGBMode(mode int) bool {
+func WebPIsRGBMode(mode ColorspaceMode) bool {
 	return mode < ModeYUV
 }
 
+// cBytes returns a view of n bytes of C memory starting at ptr. The result
+// must not outlive the C allocation.
+func cBytes(ptr uintptr, n int) []byte {
+	if ptr == 0 || n <= 0 {
+		return nil
+	}
+	return unsafe.Slice(*(**byte)(unsafe.Pointer(&ptr)), n)
+}
+
+// cString copies the NUL-terminated C string at ptr.
+func cString(ptr uintptr) string {
+	if ptr == 0 {
+		return ""
+	}
+	n := 0
+	for *(*byte)(unsafe.Add(*(*unsafe.Pointer)(unsafe.Pointer(&ptr)), n)) != 0 {
+		n++
+	}
+	return string(cBytes(ptr, n))
+}
+
 func ptrAndSize(b []byte) (*byte, uintptr) {
 	if len(b) == 0 {
 		return nil, 0
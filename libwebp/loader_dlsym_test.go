@@ -0,0 +1,32 @@
+//go:build !windows
+
+package libwebp
+
+import (
+	"testing"
+
+	"github.com/bnema/purego"
+)
+
+func TestLibraryHandle(t *testing.T) {
+	h, err := LibraryHandle()
+	if err != nil {
+		t.Fatalf("LibraryHandle() error = %v", err)
+	}
+	if h == 0 {
+		t.Fatal("LibraryHandle() = 0")
+	}
+	addr, err := purego.Dlsym(h, "WebPGetDecoderVersion")
+	if err != nil {
+		t.Fatalf("Dlsym(WebPGetDecoderVersion) error = %v", err)
+	}
+	var getVersion func() int32
+	purego.RegisterFunc(&getVersion, addr)
+	decoder, _, err := Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if got := uint32(getVersion()); got != decoder {
+		t.Fatalf("WebPGetDecoderVersion() via handle = %#x, want %#x", got, decoder)
+	}
+}
@@ -0,0 +1,87 @@
+package libwebp
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/bnema/purego-webp/internal/dynlib"
+)
+
+// loadHelperEnv makes TestLoadHelper run, in a fresh process, with the path
+// of the library to load.
+const loadHelperEnv = "LIBWEBP_TEST_LOAD_PATH"
+
+// systemLibPath returns the path of an installed libwebp shared library.
+func systemLibPath(t *testing.T) string {
+	t.Helper()
+	path := dynlib.InstalledLibPath()
+	if path == "" {
+		t.Skip("no installed libwebp found")
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := systemLibPath(t)
+
+	// The library is loaded at most once per process, so Load runs in a
+	// child process that has not touched libwebp yet.
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLoadHelper$", "-test.v")
+	cmd.Env = append(os.Environ(), loadHelperEnv+"="+path)
+	out, err := cmd.CombinedOutput()
+	if err != nil || !bytes.Contains(out, []byte("--- PASS: TestLoadHelper")) {
+		t.Fatalf("Load(%s) in child process: %v\n%s", path, err, out)
+	}
+}
+
+func TestLoadHelper(t *testing.T) {
+	path := os.Getenv(loadHelperEnv)
+	if path == "" {
+		t.Skip("only run as a child of TestLoad")
+	}
+
+	if err := Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, _, err := Version(); err != nil {
+		t.Fatalf("Version() after Load error = %v", err)
+	}
+	if err := Load(path); !errors.Is(err, ErrAlreadyLoaded) {
+		t.Fatalf("second Load() error = %v, want %v", err, ErrAlreadyLoaded)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	if err := Preload(); err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+	MustLoad()
+}
+
+func TestUnload(t *testing.T) {
+	path := systemLibPath(t)
+	if err := Preload(); err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+	if err := Unload(); err != nil {
+		t.Fatalf("Unload() error = %v", err)
+	}
+	// Load only succeeds on a loader that has not loaded yet.
+	if err := Load(path); err != nil {
+		t.Fatalf("Load() after Unload error = %v", err)
+	}
+	if _, _, err := Version(); err != nil {
+		t.Fatalf("Version() after reload error = %v", err)
+	}
+
+	// Loading on demand works after an Unload too.
+	if err := Unload(); err != nil {
+		t.Fatalf("second Unload() error = %v", err)
+	}
+	if _, _, _, _, err := WebPDecodeRGBA(encodeTestRGBA(t, 4, 4)); err != nil {
+		t.Fatalf("decode after second Unload error = %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package libwebp
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// MuxError is the WebPMuxError status returned by libwebpmux.
+type MuxError int32
+
+const (
+	MuxOK              MuxError = 1
+	MuxNotFound        MuxError = 0
+	MuxInvalidArgument MuxError = -1
+	MuxBadData         MuxError = -2
+	MuxMemoryError     MuxError = -3
+	MuxNotEnoughData   MuxError = -4
+)
+
+func (e MuxError) String() string {
+	switch e {
+	case MuxOK:
+		return "ok"
+	case MuxNotFound:
+		return "not found"
+	case MuxInvalidArgument:
+		return "invalid argument"
+	case MuxBadData:
+		return "bad data"
+	case MuxMemoryError:
+		return "memory error"
+	case MuxNotEnoughData:
+		return "not enough data"
+	default:
+		return fmt.Sprintf("MuxError(%d)", int32(e))
+	}
+}
+
+// WebPMuxCreate creates a mux object from the WebP file in data. The data is
+// copied, so it may be modified once WebPMuxCreate returns.
+func WebPMuxCreate(data []byte) (uintptr, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, ErrInvalidData
+	}
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	webpData := pinnedWebPData(&pinner, data)
+	mux := lowlevel.WebPMuxCreateInternal(&webpData, 1, lowlevel.WebPMuxABIVersion)
+	if mux == 0 {
+		return 0, ErrInvalidData
+	}
+	return mux, nil
+}
+
+// WebPMuxNew creates an empty mux object, to be filled with WebPMuxSetImage
+// and WebPMuxSetChunk.
+func WebPMuxNew() (uintptr, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return 0, err
+	}
+	mux := lowlevel.WebPNewInternal(lowlevel.WebPMuxABIVersion)
+	if mux == 0 {
+		return 0, ErrEncodeFailed
+	}
+	return mux, nil
+}
+
+// WebPMuxSetImage sets the still image of the mux object, copying bitstream,
+// which is either a WebP file or a bare VP8/VP8L bitstream. It replaces any
+// image or animation frames already present.
+func WebPMuxSetImage(mux uintptr, bitstream []byte) (MuxError, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return 0, err
+	}
+	if mux == 0 || len(bitstream) == 0 {
+		return 0, ErrInvalidData
+	}
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	image := pinnedWebPData(&pinner, bitstream)
+	return MuxError(lowlevel.WebPMuxSetImage(mux, &image, 1)), nil
+}
+
+// WebPMuxSetChunk adds or replaces the chunk with the given FourCC, copying
+// payload. Image and animation FourCCs (VP8X, ANIM, ANMF, VP8 , VP8L, ALPH)
+// are rejected with MuxInvalidArgument.
+func WebPMuxSetChunk(mux uintptr, fourcc string, payload []byte) (MuxError, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return 0, err
+	}
+	tag, err := fourccTag(fourcc)
+	if err != nil {
+		return 0, err
+	}
+	if mux == 0 {
+		return 0, ErrInvalidData
+	}
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	chunk := pinnedWebPData(&pinner, payload)
+	return MuxError(lowlevel.WebPMuxSetChunk(mux, &tag[0], &chunk, 1)), nil
+}
+
+// WebPMuxGetChunk returns a copy of the payload of the chunk with the given
+// FourCC. The status is MuxNotFound when the chunk is absent.
+func WebPMuxGetChunk(mux uintptr, fourcc string) ([]byte, MuxError, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return nil, 0, err
+	}
+	tag, err := fourccTag(fourcc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if mux == 0 {
+		return nil, 0, ErrInvalidData
+	}
+
+	var chunk WebPData
+	status := MuxError(lowlevel.WebPMuxGetChunk(mux, &tag[0], &chunk))
+	if status != MuxOK {
+		return nil, status, nil
+	}
+	return WebPDataBytes(&chunk), status, nil
+}
+
+// WebPMuxDeleteChunk removes every chunk with the given FourCC. The status is
+// MuxNotFound when there was none.
+func WebPMuxDeleteChunk(mux uintptr, fourcc string) (MuxError, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return 0, err
+	}
+	tag, err := fourccTag(fourcc)
+	if err != nil {
+		return 0, err
+	}
+	if mux == 0 {
+		return 0, ErrInvalidData
+	}
+
+	return MuxError(lowlevel.WebPMuxDeleteChunk(mux, &tag[0])), nil
+}
+
+// WebPMuxAssemble writes the mux object out as a WebP file.
+func WebPMuxAssemble(mux uintptr) ([]byte, MuxError, error) {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return nil, 0, err
+	}
+	if mux == 0 {
+		return nil, 0, ErrInvalidData
+	}
+
+	var assembled WebPData
+	status := MuxError(lowlevel.WebPMuxAssemble(mux, &assembled))
+	defer WebPDataClear(&assembled)
+	if status != MuxOK {
+		return nil, status, nil
+	}
+	return WebPDataBytes(&assembled), status, nil
+}
+
+// WebPMuxDelete destroys a mux object.
+func WebPMuxDelete(mux uintptr) error {
+	if err := lowlevel.EnsureMuxLoaded(); err != nil {
+		return err
+	}
+	if mux == 0 {
+		return nil
+	}
+
+	lowlevel.WebPMuxDelete(mux)
+	return nil
+}
+
+// WebPData is a pointer and size pair used by the mux and demux APIs. Data
+// returned by libwebp, such as an assembled file, is libwebp-owned and must
+// be released with WebPDataClear.
+type WebPData = lowlevel.WebPData
+
+// WebPDataInit resets data to the empty value, like the inline C helper of
+// the same name.
+func WebPDataInit(data *WebPData) {
+	if data != nil {
+		*data = WebPData{}
+	}
+}
+
+// WebPDataClear frees the libwebp-owned bytes of data and resets it, like the
+// inline C helper of the same name. data must not borrow Go memory.
+func WebPDataClear(data *WebPData) {
+	if data == nil {
+		return
+	}
+	if data.Bytes != 0 {
+		lowlevel.WebPFree(data.Bytes)
+	}
+	*data = WebPData{}
+}
+
+// WebPDataBytes returns an owned copy of the bytes data points to, or nil if
+// data is nil or empty. data is left untouched.
+func WebPDataBytes(data *WebPData) []byte {
+	if data == nil || data.Bytes == 0 || data.Size == 0 {
+		return nil
+	}
+	return append([]byte(nil), cBytes(data.Bytes, int(data.Size))...)
+}
+
+// pinnedWebPData pins b with pinner and returns it as a WebPData borrowing
+// the Go memory.
+func pinnedWebPData(pinner *runtime.Pinner, b []byte) lowlevel.WebPData {
+	if len(b) == 0 {
+		return lowlevel.WebPData{}
+	}
+	pinner.Pin(&b[0])
+	return lowlevel.WebPData{Bytes: uintptr(unsafe.Pointer(&b[0])), Size: uintptr(len(b))}
+}
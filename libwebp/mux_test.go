@@ -0,0 +1,48 @@
+package libwebp
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+func TestWebPDataBytes(t *testing.T) {
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		t.Fatalf("EnsureLoaded() error = %v", err)
+	}
+	// Any libwebp allocation will do; the shortcut encoder hands one out.
+	pix := make([]byte, 4*4*4)
+	var out *byte
+	size := lowlevel.WebPEncodeRGBA(&pix[0], 4, 4, 16, 75, &out)
+	if size == 0 || out == nil {
+		t.Fatal("WebPEncodeRGBA() failed")
+	}
+	want := bytes.Clone(unsafe.Slice(out, size))
+
+	data := WebPData{Bytes: uintptr(unsafe.Pointer(out)), Size: size}
+	got := WebPDataBytes(&data)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("WebPDataBytes() = %d bytes, want %d matching bytes", len(got), len(want))
+	}
+	if &got[0] == out {
+		t.Fatal("WebPDataBytes() aliases the C memory")
+	}
+
+	WebPDataClear(&data)
+	if data != (WebPData{}) {
+		t.Fatalf("WebPDataClear() left %+v", data)
+	}
+	WebPDataClear(&data)
+	WebPDataClear(nil)
+	if got := WebPDataBytes(&data); got != nil {
+		t.Fatalf("WebPDataBytes(empty) = %v, want nil", got)
+	}
+
+	data = WebPData{Bytes: 1, Size: 1}
+	WebPDataInit(&data)
+	if data != (WebPData{}) {
+		t.Fatalf("WebPDataInit() left %+v", data)
+	}
+}
@@ -0,0 +1,249 @@
+package libwebp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// newTestPicture returns an ARGB picture filled with the packed RGBA pixels
+// produced by fill. The caller must free it.
+func newTestPicture(t testing.TB, width, height int, fill func(x, y int) [4]byte) *Picture {
+	t.Helper()
+	pix := make([]byte, width*height*4)
+	for y := range height {
+		for x := range width {
+			p := fill(x, y)
+			copy(pix[(y*width+x)*4:], p[:])
+		}
+	}
+
+	var pic Picture
+	if ok, err := WebPPictureInit(&pic); err != nil || !ok {
+		t.Fatalf("WebPPictureInit() = (%v, %v)", ok, err)
+	}
+	pic.UseArgb = 1
+	pic.Width = int32(width)
+	pic.Height = int32(height)
+	if ok, err := WebPPictureImportRGBA(&pic, pix, width*4); err != nil || !ok {
+		t.Fatalf("WebPPictureImportRGBA() = (%v, %v)", ok, err)
+	}
+	return &pic
+}
+
+func argbAt(pic *Picture, x, y int) uint32 {
+	argb := cBytes(pic.Argb, int(pic.ArgbStride)*int(pic.Height)*4)
+	return binary.NativeEndian.Uint32(argb[(y*int(pic.ArgbStride)+x)*4:])
+}
+
+func TestPictureARGBToYUVARoundTrip(t *testing.T) {
+	pic := newTestPicture(t, 8, 8, func(x, y int) [4]byte { return [4]byte{200, 100, 50, 255} })
+	defer WebPPictureFree(pic)
+
+	if ok, err := WebPPictureARGBToYUVA(pic, CSPYUV420); err != nil || !ok {
+		t.Fatalf("WebPPictureARGBToYUVA() = (%v, %v)", ok, err)
+	}
+	if pic.UseArgb != 0 {
+		t.Fatal("picture still uses ARGB after conversion")
+	}
+	if ok, err := WebPPictureYUVAToARGB(pic); err != nil || !ok {
+		t.Fatalf("WebPPictureYUVAToARGB() = (%v, %v)", ok, err)
+	}
+
+	got := argbAt(pic, 3, 3)
+	want := [4]int{255, 200, 100, 50}
+	for i, shift := range []uint{24, 16, 8, 0} {
+		c := int(got>>shift) & 0xff
+		if diff := c - want[i]; diff < -3 || diff > 3 {
+			t.Fatalf("ARGB = %#08x, want ~%v", got, want)
+		}
+	}
+}
+
+func TestPictureImportRejectsShortBuffer(t *testing.T) {
+	var pic Picture
+	if _, err := WebPPictureInit(&pic); err != nil {
+		t.Fatal(err)
+	}
+	pic.Width, pic.Height = 4, 4
+	if _, err := WebPPictureImportRGBA(&pic, make([]byte, 4*4*4-1), 16); err == nil {
+		t.Fatal("WebPPictureImportRGBA accepted a short buffer")
+	}
+}
+
+func TestPictureImportYUV420(t *testing.T) {
+	var pic Picture
+	if _, err := WebPPictureInit(&pic); err != nil {
+		t.Fatal(err)
+	}
+	pic.Width, pic.Height = 5, 3
+	defer WebPPictureFree(&pic)
+
+	if _, err := WebPPictureImportYUV420(&pic, make([]byte, 15), make([]byte, 5), make([]byte, 6), 5, 3); err == nil {
+		t.Fatal("WebPPictureImportYUV420 accepted a short chroma plane")
+	}
+
+	y := bytes.Repeat([]byte{81}, 15)
+	u := bytes.Repeat([]byte{90}, 6)
+	v := bytes.Repeat([]byte{240}, 6)
+	if ok, err := WebPPictureImportYUV420(&pic, y, u, v, 5, 3); err != nil || !ok {
+		t.Fatalf("WebPPictureImportYUV420() = (%v, %v)", ok, err)
+	}
+	if pic.UseArgb != 0 || pic.Y == 0 || pic.U == 0 || pic.V == 0 {
+		t.Fatalf("picture not in YUV mode: UseArgb=%d", pic.UseArgb)
+	}
+	// BT.601 limited-range (81, 90, 240) is pure red.
+	if ok, err := WebPPictureYUVAToARGB(&pic); err != nil || !ok {
+		t.Fatalf("WebPPictureYUVAToARGB() = (%v, %v)", ok, err)
+	}
+	if got := argbAt(&pic, 4, 2); got>>16&0xff < 250 || got>>8&0xff > 5 || got&0xff > 5 {
+		t.Fatalf("pixel = %#08x, want about 0xffff0000", got)
+	}
+}
+
+func TestPictureSharpConversionsEncode(t *testing.T) {
+	checker := func(x, y int) [4]byte {
+		if (x/2+y/2)%2 == 0 {
+			return [4]byte{255, 0, 0, 255}
+		}
+		return [4]byte{0, 0, 255, 255}
+	}
+	convert := map[string]func(*Picture) (bool, error){
+		"sharp": WebPPictureSharpARGBToYUVA,
+		"smart": WebPPictureSmartARGBToYUVA,
+	}
+	for name, fn := range convert {
+		t.Run(name, func(t *testing.T) {
+			pic := newTestPicture(t, 16, 16, checker)
+			defer WebPPictureFree(pic)
+
+			if ok, err := fn(pic); err != nil || !ok {
+				t.Fatalf("convert = (%v, %v)", ok, err)
+			}
+			if pic.UseArgb != 0 || pic.Y == 0 {
+				t.Fatal("picture has no YUV planes after conversion")
+			}
+
+			var config Config
+			if ok, err := WebPConfigInit(&config); err != nil || !ok {
+				t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+			}
+			if ok, err := WebPEncode(&config, pic); err != nil || !ok {
+				t.Fatalf("WebPEncode() = (%v, %v), error code %d", ok, err, pic.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestPictureProgressHookAbort(t *testing.T) {
+	pic := newTestPicture(t, 128, 128, func(x, y int) [4]byte { return [4]byte{byte(x * y), byte(x), byte(y), 255} })
+	defer WebPPictureFree(pic)
+
+	var config Config
+	if ok, err := WebPConfigInit(&config); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+
+	var calls []int
+	SetPictureProgressHook(pic, func(percent int) bool {
+		calls = append(calls, percent)
+		return len(calls) < 2
+	})
+	defer SetPictureProgressHook(pic, nil)
+
+	ok, err := WebPEncode(&config, pic)
+	var encErr *EncodeError
+	if ok || !errors.As(err, &encErr) {
+		t.Fatalf("WebPEncode() = (%v, %v), want aborted", ok, err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("progress hook called %d times, want 2", len(calls))
+	}
+	if encErr.Code != VP8EncErrorUserAbort || pic.ErrorCode != int32(VP8EncErrorUserAbort) {
+		t.Fatalf("error code = %v (picture %d), want UserAbort", encErr.Code, pic.ErrorCode)
+	}
+
+	SetPictureProgressHook(pic, nil)
+	if pic.ProgressHook != 0 || pic.UserData != 0 {
+		t.Fatal("SetPictureProgressHook(nil) left the hook installed")
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestWebPEncodeToWriter(t *testing.T) {
+	fill := func(x, y int) [4]byte { return [4]byte{byte(x * 16), byte(y * 16), byte(x ^ y), 255} }
+	pic := newTestPicture(t, 16, 16, fill)
+	defer WebPPictureFree(pic)
+
+	var config Config
+	if ok, err := WebPConfigInit(&config); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+	config.Lossless = 1
+
+	writer, customPtr := pic.Writer, pic.CustomPtr
+	var buf bytes.Buffer
+	if err := WebPEncodeToWriter(&config, pic, &buf); err != nil {
+		t.Fatalf("WebPEncodeToWriter() error = %v", err)
+	}
+	if pic.Writer != writer || pic.CustomPtr != customPtr {
+		t.Fatal("WebPEncodeToWriter did not restore the picture writer")
+	}
+
+	pix, w, h, stride, err := WebPDecodeRGBA(buf.Bytes())
+	if err != nil {
+		t.Fatalf("WebPDecodeRGBA() error = %v", err)
+	}
+	if w != 16 || h != 16 {
+		t.Fatalf("decoded size = %dx%d, want 16x16", w, h)
+	}
+	for y := range 16 {
+		for x := range 16 {
+			want := fill(x, y)
+			if got := pix[y*stride+x*4:][:4]; !bytes.Equal(got, want[:]) {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	writeErr := errors.New("disk full")
+	err = WebPEncodeToWriter(&config, pic, failingWriter{writeErr})
+	if !errors.Is(err, ErrEncodeFailed) || !errors.Is(err, writeErr) {
+		t.Fatalf("WebPEncodeToWriter(failing) error = %v, want ErrEncodeFailed and write error", err)
+	}
+}
+
+func TestEncodePictureMatchesShortcut(t *testing.T) {
+	const width, height = 24, 16
+	pix := make([]byte, width*height*4)
+	for i := range pix {
+		pix[i] = byte(i*13 + i/97)
+	}
+
+	var config Config
+	if ok, err := WebPConfigPreset(&config, PresetDefault, 60); err != nil || !ok {
+		t.Fatalf("WebPConfigPreset() = (%v, %v)", ok, err)
+	}
+	got, err := EncodePicture(&config, pix, width, height, width*4, 4)
+	if err != nil {
+		t.Fatalf("EncodePicture() error = %v", err)
+	}
+	want, err := WebPEncodeRGBA(pix, width, height, width*4, 60)
+	if err != nil {
+		t.Fatalf("WebPEncodeRGBA() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("EncodePicture() produced %d bytes differing from WebPEncodeRGBA's %d", len(got), len(want))
+	}
+
+	if _, err := EncodePicture(&config, pix, width, height, width*4, 2); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("EncodePicture(bpp 2) error = %v, want %v", err, ErrInvalidData)
+	}
+	if _, err := EncodePicture(&config, pix[:10], width, height, width*4, 4); err == nil {
+		t.Fatal("EncodePicture(short buffer) succeeded")
+	}
+}
@@ -0,0 +1,63 @@
+package libwebp
+
+import (
+	"sync"
+
+	lowlevel "github.com/bnema/purego-webp/internal/libwebp"
+)
+
+// decodePool holds released DecodeRGBAPooled buffers.
+var decodePool sync.Pool // of *[]byte
+
+// DecodeRGBAPooled decodes data to packed RGBA like WebPDecodeRGBA, but into
+// a buffer taken from a package pool, so bursts of decodes reuse their
+// output slices instead of allocating one per image.
+//
+// Call release once pix is no longer needed to return the buffer to the
+// pool. pix must not be used, or retained, after release; later decodes
+// overwrite it. Not calling release is safe and leaves the buffer to the
+// garbage collector. release is a no-op on error.
+func DecodeRGBAPooled(data []byte) (pix []byte, width, height, stride int, release func(), err error) {
+	noop := func() {}
+	if err := lowlevel.EnsureLoaded(); err != nil {
+		return nil, 0, 0, 0, noop, err
+	}
+	if len(data) == 0 {
+		return nil, 0, 0, 0, noop, ErrInvalidData
+	}
+
+	var w, h int32
+	if lowlevel.WebPGetInfo(&data[0], uintptr(len(data)), &w, &h) == 0 {
+		return nil, 0, 0, 0, noop, decodeFailure(data)
+	}
+	width, height = int(w), int(h)
+	stride, size, err := checkedDecodeLayout(width, height, 4)
+	if err != nil {
+		return nil, 0, 0, 0, noop, err
+	}
+
+	buf := pooledBuffer(size)
+	if err := decodeIntoWithInfo(data, *buf, stride, width, height, 4, lowlevel.WebPDecodeRGBAInto); err != nil {
+		decodePool.Put(buf)
+		return nil, 0, 0, 0, noop, err
+	}
+
+	var once sync.Once
+	release = func() { once.Do(func() { decodePool.Put(buf) }) }
+	return *buf, width, height, stride, release, nil
+}
+
+// pooledBuffer returns a pooled buffer resliced to size, or a new one when
+// the pooled buffer is too small.
+func pooledBuffer(size int) *[]byte {
+	if buf, ok := decodePool.Get().(*[]byte); ok {
+		if cap(*buf) >= size {
+			*buf = (*buf)[:size]
+			return buf
+		}
+		// Too small for this image: let it go so the pool converges on
+		// the sizes actually decoded.
+	}
+	buf := make([]byte, size)
+	return &buf
+}
@@ -0,0 +1,97 @@
+package libwebp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func encodeTestRGBA(t testing.TB, width, height int) []byte {
+	t.Helper()
+	pix := make([]byte, width*height*4)
+	for i := range pix {
+		pix[i] = byte(i * 7)
+	}
+	for i := 3; i < len(pix); i += 4 {
+		pix[i] = 255
+	}
+	data, err := WebPEncodeLosslessRGBA(pix, width, height, width*4)
+	if err != nil {
+		t.Fatalf("WebPEncodeLosslessRGBA() error = %v", err)
+	}
+	return data
+}
+
+func TestDecodeRGBAPooledReusesReleasedBuffer(t *testing.T) {
+	data := encodeTestRGBA(t, 16, 8)
+	want, _, _, _, err := WebPDecodeRGBA(data)
+	if err != nil {
+		t.Fatalf("WebPDecodeRGBA() error = %v", err)
+	}
+
+	// sync.Pool may drop items (at random under the race detector), so
+	// allow a few rounds before concluding the buffer is never reused.
+	reused := false
+	for range 10 {
+		pix, w, h, stride, release, err := DecodeRGBAPooled(data)
+		if err != nil {
+			t.Fatalf("DecodeRGBAPooled() error = %v", err)
+		}
+		if w != 16 || h != 8 || stride != 64 || !bytes.Equal(pix, want) {
+			t.Fatalf("DecodeRGBAPooled() = %dx%d stride %d, pixels match %v", w, h, stride, bytes.Equal(pix, want))
+		}
+		first := &pix[0]
+		release()
+		release() // idempotent
+
+		pix, _, _, _, release, err = DecodeRGBAPooled(data)
+		if err != nil {
+			t.Fatalf("DecodeRGBAPooled() error = %v", err)
+		}
+		if !bytes.Equal(pix, want) {
+			t.Fatal("DecodeRGBAPooled() from a reused buffer returned wrong pixels")
+		}
+		reused = &pix[0] == first
+		release()
+		if reused {
+			break
+		}
+	}
+	if !reused {
+		t.Fatal("DecodeRGBAPooled() never reused a released buffer")
+	}
+}
+
+func TestDecodeRGBAPooledInvalidData(t *testing.T) {
+	_, _, _, _, release, err := DecodeRGBAPooled(nil)
+	if !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("DecodeRGBAPooled(nil) error = %v, want %v", err, ErrInvalidData)
+	}
+	release()
+
+	if _, _, _, _, _, err := DecodeRGBAPooled([]byte("RIFF\x00\x00\x00\x00WEBPVP8 ")); !errors.Is(err, ErrDecodeFailed) {
+		t.Fatalf("DecodeRGBAPooled(truncated) error = %v, want %v", err, ErrDecodeFailed)
+	}
+}
+
+func BenchmarkDecodeRGBA(b *testing.B) {
+	data := encodeTestRGBA(b, 256, 256)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, _, _, err := WebPDecodeRGBA(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeRGBAPooled(b *testing.B) {
+	data := encodeTestRGBA(b, 256, 256)
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _, _, _, release, err := DecodeRGBAPooled(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		release()
+	}
+}
@@ -0,0 +1,95 @@
+package libwebp
+
+import "fmt"
+
+// StatusError is a decode failure carrying the non-OK VP8StatusCode reported
+// by libwebp. It matches ErrDecodeFailed with errors.Is.
+type StatusError struct {
+	Status VP8StatusCode
+}
+
+func (e *StatusError) Error() string {
+	return ErrDecodeFailed.Error() + ": " + e.Status.String()
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrDecodeFailed
+}
+
+// ErrorFromStatus returns nil for VP8StatusOK and a *StatusError otherwise.
+func ErrorFromStatus(s VP8StatusCode) error {
+	if s == VP8StatusOK {
+		return nil
+	}
+	return &StatusError{Status: s}
+}
+
+// WebPEncodingError is the detailed encoder error libwebp stores in
+// Picture.ErrorCode when WebPEncode fails.
+type WebPEncodingError int32
+
+const (
+	VP8EncOK                        WebPEncodingError = 0
+	VP8EncErrorOutOfMemory          WebPEncodingError = 1
+	VP8EncErrorBitstreamOutOfMemory WebPEncodingError = 2
+	VP8EncErrorNullParameter        WebPEncodingError = 3
+	VP8EncErrorInvalidConfiguration WebPEncodingError = 4
+	VP8EncErrorBadDimension         WebPEncodingError = 5
+	VP8EncErrorPartition0Overflow   WebPEncodingError = 6
+	VP8EncErrorPartitionOverflow    WebPEncodingError = 7
+	VP8EncErrorBadWrite             WebPEncodingError = 8
+	VP8EncErrorFileTooBig           WebPEncodingError = 9
+	VP8EncErrorUserAbort            WebPEncodingError = 10
+	VP8EncErrorLast                 WebPEncodingError = 11
+)
+
+func (e WebPEncodingError) String() string {
+	switch e {
+	case VP8EncOK:
+		return "OK"
+	case VP8EncErrorOutOfMemory:
+		return "OutOfMemory"
+	case VP8EncErrorBitstreamOutOfMemory:
+		return "BitstreamOutOfMemory"
+	case VP8EncErrorNullParameter:
+		return "NullParameter"
+	case VP8EncErrorInvalidConfiguration:
+		return "InvalidConfiguration"
+	case VP8EncErrorBadDimension:
+		return "BadDimension"
+	case VP8EncErrorPartition0Overflow:
+		return "Partition0Overflow"
+	case VP8EncErrorPartitionOverflow:
+		return "PartitionOverflow"
+	case VP8EncErrorBadWrite:
+		return "BadWrite"
+	case VP8EncErrorFileTooBig:
+		return "FileTooBig"
+	case VP8EncErrorUserAbort:
+		return "UserAbort"
+	case VP8EncErrorLast:
+		return "Last"
+	default:
+		return fmt.Sprintf("WebPEncodingError(%d)", int32(e))
+	}
+}
+
+// EncodeError is an encode failure carrying the WebPEncodingError reported
+// by libwebp. It matches ErrEncodeFailed with errors.Is.
+type EncodeError struct {
+	Code WebPEncodingError
+}
+
+func (e *EncodeError) Error() string {
+	return ErrEncodeFailed.Error() + ": " + e.Code.String()
+}
+
+func (e *EncodeError) Unwrap() error {
+	return ErrEncodeFailed
+}
+
+// encodeErrorFrom returns the *EncodeError for a failed WebPEncode on
+// picture.
+func encodeErrorFrom(picture *Picture) error {
+	return &EncodeError{Code: WebPEncodingError(picture.ErrorCode)}
+}
@@ -0,0 +1,167 @@
+package libwebp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVP8StatusCodeString(t *testing.T) {
+	tests := []struct {
+		code VP8StatusCode
+		want string
+	}{
+		{VP8StatusOK, "OK"},
+		{VP8StatusOutOfMemory, "OutOfMemory"},
+		{VP8StatusInvalidParam, "InvalidParam"},
+		{VP8StatusBitstreamError, "BitstreamError"},
+		{VP8StatusUnsupportedFeat, "UnsupportedFeature"},
+		{VP8StatusSuspended, "Suspended"},
+		{VP8StatusUserAbort, "UserAbort"},
+		{VP8StatusNotEnoughData, "NotEnoughData"},
+		{VP8StatusCode(42), "VP8StatusCode(42)"},
+	}
+	for _, tt := range tests {
+		if got := tt.code.String(); got != tt.want {
+			t.Errorf("VP8StatusCode(%d).String() = %q, want %q", int32(tt.code), got, tt.want)
+		}
+	}
+}
+
+func TestErrorFromStatus(t *testing.T) {
+	if err := ErrorFromStatus(VP8StatusOK); err != nil {
+		t.Fatalf("ErrorFromStatus(OK) = %v, want nil", err)
+	}
+
+	err := ErrorFromStatus(VP8StatusBitstreamError)
+	if !errors.Is(err, ErrDecodeFailed) {
+		t.Fatalf("errors.Is(%v, ErrDecodeFailed) = false", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status != VP8StatusBitstreamError {
+		t.Fatalf("errors.As(%v) status = %v, want BitstreamError", err, statusErr)
+	}
+	if got, want := err.Error(), "libwebp: decode failed: BitstreamError"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestWebPDecodeWithConfigReturnsStatusError(t *testing.T) {
+	pic := newTestPicture(t, 8, 8, func(x, y int) [4]byte { return [4]byte{byte(x * 30), byte(y * 30), 0, 255} })
+	defer WebPPictureFree(pic)
+	var config Config
+	if ok, err := WebPConfigInit(&config); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+	data, err := WebPEncodeMemory(&config, pic)
+	if err != nil {
+		t.Fatalf("WebPEncodeMemory() error = %v", err)
+	}
+
+	var decConfig DecoderConfig
+	if ok, err := WebPInitDecoderConfig(&decConfig); err != nil || !ok {
+		t.Fatalf("WebPInitDecoderConfig() = (%v, %v)", ok, err)
+	}
+	decConfig.Output.Colorspace = int32(ModeRGBA)
+	_, _, _, _, err = WebPDecodeWithConfig(data[:len(data)/2], &decConfig)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || !errors.Is(err, ErrDecodeFailed) {
+		t.Fatalf("WebPDecodeWithConfig(truncated) error = %v, want *StatusError", err)
+	}
+	if statusErr.Status != VP8StatusNotEnoughData {
+		t.Fatalf("status = %v, want NotEnoughData", statusErr.Status)
+	}
+}
+
+func TestWebPEncodingErrorString(t *testing.T) {
+	for code, want := range []string{
+		"OK", "OutOfMemory", "BitstreamOutOfMemory", "NullParameter", "InvalidConfiguration", "BadDimension",
+		"Partition0Overflow", "PartitionOverflow", "BadWrite", "FileTooBig", "UserAbort", "Last",
+	} {
+		if got := WebPEncodingError(code).String(); got != want {
+			t.Errorf("WebPEncodingError(%d).String() = %q, want %q", code, got, want)
+		}
+	}
+	if got := WebPEncodingError(99).String(); got != "WebPEncodingError(99)" {
+		t.Errorf("WebPEncodingError(99).String() = %q", got)
+	}
+}
+
+func TestWebPEncodeReturnsEncodeError(t *testing.T) {
+	// Wider than the 16383 pixel WebP limit, but cheap to allocate.
+	pic := newTestPicture(t, 20000, 1, func(x, y int) [4]byte { return [4]byte{0, 0, 0, 255} })
+	defer WebPPictureFree(pic)
+	var config Config
+	if ok, err := WebPConfigInit(&config); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+
+	ok, err := WebPEncode(&config, pic)
+	var encErr *EncodeError
+	if ok || !errors.As(err, &encErr) || !errors.Is(err, ErrEncodeFailed) {
+		t.Fatalf("WebPEncode(20000x1) = (%v, %v), want *EncodeError", ok, err)
+	}
+	if encErr.Code != VP8EncErrorBadDimension {
+		t.Fatalf("code = %v, want BadDimension", encErr.Code)
+	}
+	if _, err := WebPEncodeMemory(&config, pic); !errors.As(err, &encErr) || encErr.Code != VP8EncErrorBadDimension {
+		t.Fatalf("WebPEncodeMemory(20000x1) error = %v, want BadDimension", err)
+	}
+}
+
+func TestShortcutDecodeFailureCarriesStatus(t *testing.T) {
+	pic := newTestPicture(t, 64, 64, func(x, y int) [4]byte { return [4]byte{byte(x * y), byte(x), byte(y), 255} })
+	defer WebPPictureFree(pic)
+	var config Config
+	if ok, err := WebPConfigInit(&config); err != nil || !ok {
+		t.Fatalf("WebPConfigInit() = (%v, %v)", ok, err)
+	}
+	data, err := WebPEncodeMemory(&config, pic)
+	if err != nil {
+		t.Fatalf("WebPEncodeMemory() error = %v", err)
+	}
+
+	truncated := data[:len(data)/2]
+	// Corrupt the VP8 frame start code, which sits after the RIFF (12),
+	// chunk (8) and frame tag (3) headers.
+	corrupt := append([]byte(nil), data...)
+	corrupt[12+8+3] ^= 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+		want VP8StatusCode
+	}{
+		{name: "truncated", data: truncated, want: VP8StatusNotEnoughData},
+		{name: "corrupt", data: corrupt, want: VP8StatusBitstreamError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, _, err := WebPDecodeRGBA(tt.data)
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.Status != tt.want {
+				t.Fatalf("WebPDecodeRGBA() error = %v, want status %v", err, tt.want)
+			}
+
+			buf := make([]byte, 64*64*4)
+			_, _, err = WebPDecodeRGBAInto(tt.data, buf, 64*4)
+			if !errors.As(err, &statusErr) || statusErr.Status != tt.want || !errors.Is(err, ErrDecodeFailed) {
+				t.Fatalf("WebPDecodeRGBAInto() error = %v, want status %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortcutDecodeFailureWithValidHeaders(t *testing.T) {
+	// A complete file whose lossless image data is damaged: the headers
+	// parse, so there is no status to report and the data is not short.
+	data := encodeTestRGBA(t, 64, 64)
+	corrupt := append([]byte(nil), data...)
+	for i := len(data) / 2; i < len(data)/2+8; i++ {
+		corrupt[i] ^= 0xff
+	}
+	_, _, _, _, err := WebPDecodeRGBA(corrupt)
+	var statusErr *StatusError
+	if !errors.Is(err, ErrDecodeFailed) || errors.As(err, &statusErr) {
+		t.Fatalf("WebPDecodeRGBA(corrupt body) error = %v, want plain %v", err, ErrDecodeFailed)
+	}
+}
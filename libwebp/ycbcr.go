@@ -0,0 +1,33 @@
+package libwebp
+
+import "image"
+
+// YUVToYCbCr wraps 4:2:0 planes, as returned by WebPDecodeYUV, in an
+// *image.YCbCr of size w by h. The image aliases y, u and v, which share
+// uvStride like image.YCbCr's Cb and Cr planes. It returns nil if the
+// dimensions are not positive, a stride is narrower than its plane, or a
+// plane is too short for its stride.
+//
+// The samples are libwebp's limited-range BT.601 values, while image.YCbCr
+// converts to RGB as full-range JFIF, so colors read through At come out
+// slightly washed out; webp.DecodeYCbCr expands the range for that use.
+func YUVToYCbCr(y, u, v []byte, w, h, yStride, uvStride int) *image.YCbCr {
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	uvW, uvH := (w+1)/2, (h+1)/2
+	if yStride < w || uvStride < uvW ||
+		len(y) < yStride*(h-1)+w ||
+		len(u) < uvStride*(uvH-1)+uvW || len(v) < uvStride*(uvH-1)+uvW {
+		return nil
+	}
+	return &image.YCbCr{
+		Y:              y,
+		Cb:             u,
+		Cr:             v,
+		YStride:        yStride,
+		CStride:        uvStride,
+		SubsampleRatio: image.YCbCrSubsampleRatio420,
+		Rect:           image.Rect(0, 0, w, h),
+	}
+}
@@ -0,0 +1,55 @@
+package libwebp
+
+import "testing"
+
+func TestYUVToYCbCr(t *testing.T) {
+	// Four flat 8x8 quadrants, so that chroma upsampling does not blur the
+	// sampled pixels.
+	const size = 16
+	quadrants := [4][3]byte{{200, 40, 40}, {40, 160, 60}, {50, 70, 220}, {230, 230, 90}}
+	pix := make([]byte, size*size*4)
+	for py := range size {
+		for px := range size {
+			q := quadrants[py/8*2+px/8]
+			i := (py*size + px) * 4
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = q[0], q[1], q[2], 255
+		}
+	}
+	data, err := WebPEncodeRGBA(pix, size, size, size*4, 95)
+	if err != nil {
+		t.Fatalf("WebPEncodeRGBA() error = %v", err)
+	}
+	rgba, _, _, stride, err := WebPDecodeRGBA(data)
+	if err != nil {
+		t.Fatalf("WebPDecodeRGBA() error = %v", err)
+	}
+	y, u, v, w, h, yStride, uvStride, err := WebPDecodeYUV(data)
+	if err != nil {
+		t.Fatalf("WebPDecodeYUV() error = %v", err)
+	}
+
+	img := YUVToYCbCr(y, u, v, w, h, yStride, uvStride)
+	if img == nil || img.Rect.Dx() != size || img.Rect.Dy() != size {
+		t.Fatalf("YUVToYCbCr() = %v, want a %dx%d image", img, size, size)
+	}
+	for _, p := range [][2]int{{3, 3}, {12, 4}, {4, 12}, {11, 11}} {
+		c := img.YCbCrAt(p[0], p[1])
+		// BT.601 limited-range conversion, as libwebp does.
+		yy := 1.164 * (float64(c.Y) - 16)
+		cb, cr := float64(c.Cb)-128, float64(c.Cr)-128
+		got := [3]float64{yy + 1.596*cr, yy - 0.391*cb - 0.813*cr, yy + 2.018*cb}
+		want := rgba[p[1]*stride+p[0]*4:][:3]
+		for i := range 3 {
+			if d := got[i] - float64(want[i]); d > 3 || d < -3 {
+				t.Fatalf("pixel %v channel %d = %.1f, RGBA decode has %d", p, i, got[i], want[i])
+			}
+		}
+	}
+
+	if YUVToYCbCr(y, u, v, w, h, w-1, uvStride) != nil {
+		t.Fatal("YUVToYCbCr(narrow stride) != nil")
+	}
+	if YUVToYCbCr(y[:yStride*(h-1)+w-1], u, v, w, h, yStride, uvStride) != nil {
+		t.Fatal("YUVToYCbCr(short Y plane) != nil")
+	}
+}
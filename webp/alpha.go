@@ -0,0 +1,58 @@
+package webp
+
+import "bytes"
+
+// PremultiplyAlpha converts packed straight-alpha RGBA pixels, as produced by
+// libwebp and image.NRGBA, to premultiplied alpha, as used by image.RGBA, in
+// place. Each channel becomes round(c*a/255). Trailing bytes that do not
+// form a whole pixel are left untouched.
+func PremultiplyAlpha(pix []byte) {
+	for i := 0; i+4 <= len(pix); i += 4 {
+		switch a := uint32(pix[i+3]); a {
+		case 0xff:
+		case 0:
+			pix[i], pix[i+1], pix[i+2] = 0, 0, 0
+		default:
+			pix[i] = uint8((uint32(pix[i])*a + 127) / 255)
+			pix[i+1] = uint8((uint32(pix[i+1])*a + 127) / 255)
+			pix[i+2] = uint8((uint32(pix[i+2])*a + 127) / 255)
+		}
+	}
+}
+
+// UnpremultiplyAlpha converts packed premultiplied RGBA pixels to straight
+// alpha in place. Each channel becomes round(c*255/a), saturating at 255 for
+// channels larger than their alpha. Fully transparent pixels keep their RGB
+// values, since no color can be recovered from them. Unpremultiplying then
+// premultiplying again gives back the original pixels.
+func UnpremultiplyAlpha(pix []byte) {
+	for i := 0; i+4 <= len(pix); i += 4 {
+		switch a := uint32(pix[i+3]); a {
+		case 0xff, 0:
+		default:
+			pix[i] = unpremultiply(pix[i], a)
+			pix[i+1] = unpremultiply(pix[i+1], a)
+			pix[i+2] = unpremultiply(pix[i+2], a)
+		}
+	}
+}
+
+func unpremultiply(c uint8, a uint32) uint8 {
+	return uint8(min((uint32(c)*255+a/2)/a, 255))
+}
+
+// PremultipliedAlpha is like PremultiplyAlpha but returns a converted copy
+// of pix, leaving pix unchanged.
+func PremultipliedAlpha(pix []byte) []byte {
+	out := bytes.Clone(pix)
+	PremultiplyAlpha(out)
+	return out
+}
+
+// UnpremultipliedAlpha is like UnpremultiplyAlpha but returns a converted
+// copy of pix, leaving pix unchanged.
+func UnpremultipliedAlpha(pix []byte) []byte {
+	out := bytes.Clone(pix)
+	UnpremultiplyAlpha(out)
+	return out
+}
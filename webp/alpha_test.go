@@ -0,0 +1,68 @@
+package webp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPremultiplyAlpha(t *testing.T) {
+	straight := []byte{
+		200, 100, 50, 255,
+		200, 100, 50, 128,
+		255, 1, 0, 1,
+		9, 8, 7, 0,
+	}
+	premultiplied := []byte{
+		200, 100, 50, 255,
+		100, 50, 25, 128,
+		1, 0, 0, 1,
+		0, 0, 0, 0,
+	}
+	if got := PremultipliedAlpha(straight); !bytes.Equal(got, premultiplied) {
+		t.Fatalf("PremultipliedAlpha() = %v, want %v", got, premultiplied)
+	}
+
+	// A transparent pixel keeps its RGB, and alpha 1 cannot carry color.
+	want := []byte{
+		200, 100, 50, 255,
+		199, 100, 50, 128,
+		255, 0, 0, 1,
+		9, 8, 7, 0,
+	}
+	pix := bytes.Clone(premultiplied)
+	pix[12], pix[13], pix[14] = 9, 8, 7
+	UnpremultiplyAlpha(pix)
+	if !bytes.Equal(pix, want) {
+		t.Fatalf("UnpremultiplyAlpha() = %v, want %v", pix, want)
+	}
+
+	// Saturates channels above alpha, and ignores a trailing partial pixel.
+	if got := UnpremultipliedAlpha([]byte{200, 10, 0, 100, 7, 7}); !bytes.Equal(got, []byte{255, 26, 0, 100, 7, 7}) {
+		t.Fatalf("UnpremultipliedAlpha(invalid) = %v", got)
+	}
+}
+
+func TestPremultiplyAlphaRoundTrip(t *testing.T) {
+	// Every valid premultiplied value survives unpremultiplying and
+	// premultiplying again.
+	var pix []byte
+	for a := range 256 {
+		for c := 0; c <= a; c++ {
+			pix = append(pix, uint8(c), uint8(c/2), uint8(a-c), uint8(a))
+		}
+	}
+	got := UnpremultipliedAlpha(pix)
+	PremultiplyAlpha(got)
+	if !bytes.Equal(got, pix) {
+		t.Fatal("PremultiplyAlpha(UnpremultiplyAlpha(p)) != p")
+	}
+
+	// Opaque pixels are unaffected in both directions.
+	opaque := []byte{1, 2, 3, 255, 250, 128, 0, 255}
+	if got := PremultipliedAlpha(opaque); !bytes.Equal(got, opaque) {
+		t.Fatalf("PremultipliedAlpha(opaque) = %v", got)
+	}
+	if got := UnpremultipliedAlpha(opaque); !bytes.Equal(got, opaque) {
+		t.Fatalf("UnpremultipliedAlpha(opaque) = %v", got)
+	}
+}
@@ -0,0 +1,292 @@
+package webp
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"time"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// ErrInvalidAnimation indicates an Animation that cannot be encoded: no
+// frames, frames without an image or of differing sizes, or delays shorter
+// than a millisecond or overlong.
+var ErrInvalidAnimation = errors.New("webp: invalid animation")
+
+// Animation is a sequence of frames shown on a shared canvas.
+type Animation struct {
+	// Frames are the fully composited canvases in display order. All frames
+	// have the canvas size.
+	Frames []Frame
+	// LoopCount is the number of times the animation plays, in [0, 65535];
+	// 0 loops forever. EncodeAll uses it when called with nil options and
+	// AnimEncodeOptions.LoopCount otherwise.
+	LoopCount int
+}
+
+// AnimEncodeOptions configures EncodeAll. The zero value encodes every frame
+// with the Encode defaults, loops forever and keeps libwebp's default
+// background.
+type AnimEncodeOptions struct {
+	// EncodeOptions apply to every frame, as for Encode.
+	EncodeOptions
+	// LoopCount is the number of times the animation plays, in [0, 65535];
+	// 0 loops forever.
+	LoopCount int
+	// BackgroundColor is the canvas background color stored in the ANIM
+	// chunk, a hint that viewers may use instead of transparency. nil keeps
+	// libwebp's default, opaque white.
+	BackgroundColor color.Color
+}
+
+// encodeOptions returns the per-frame options, nil for nil o.
+func (o *AnimEncodeOptions) encodeOptions() *EncodeOptions {
+	if o == nil {
+		return nil
+	}
+	return &o.EncodeOptions
+}
+
+// animBackground packs c in the ANIM chunk byte order: blue, green, red and
+// alpha from the most significant byte down.
+func animBackground(c color.Color) uint32 {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return uint32(n.B)<<24 | uint32(n.G)<<16 | uint32(n.R)<<8 | uint32(n.A)
+}
+
+// Frame is one canvas of an Animation.
+type Frame struct {
+	Image image.Image
+	// Delay is how long the frame is shown, with millisecond precision.
+	// EncodeAll requires at least a millisecond.
+	Delay time.Duration
+
+	// The fields below describe the sub-frame stored in the file, for
+	// callers that work with sub-frames rather than composited canvases.
+	// DecodeAll fills them in; EncodeAll ignores them and lets the encoder
+	// choose its own sub-frames.
+
+	// Bounds is the rectangle of the canvas the stored sub-frame covers.
+	Bounds image.Rectangle
+	// Dispose is what happened to Bounds after the frame was shown, before
+	// the next one was drawn.
+	Dispose libwebp.DisposeMethod
+	// Blend is how the sub-frame was drawn over the previous canvas.
+	Blend libwebp.BlendMethod
+}
+
+// DecodeAll reads an animated WebP from r and returns all of its frames as
+// *image.NRGBA canvases, composited by libwebp like browsers do: each frame
+// is blended or copied per its blend method, and cleared to transparent
+// after display when disposed to background. A still WebP decodes to a
+// single frame with zero delay covering the canvas. DecodeAll requires
+// libwebpdemux.
+func DecodeAll(r io.Reader) (*Animation, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var info libwebp.AnimInfo
+	anim := new(Animation)
+	err = walkFrames(data, &info, func(_ int, img image.Image, delay time.Duration) error {
+		anim.Frames = append(anim.Frames, Frame{Image: img, Delay: delay})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := readSubFrames(data, anim.Frames); err != nil {
+		return nil, err
+	}
+	anim.LoopCount = int(info.LoopCount)
+	return anim, nil
+}
+
+// readSubFrames sets the sub-frame fields of frames from the frames of the
+// container in data, in order.
+func readSubFrames(data []byte, frames []Frame) error {
+	dmux, err := libwebp.WebPDemux(data)
+	if err != nil {
+		return err
+	}
+	defer libwebp.WebPDemuxDelete(dmux)
+
+	var iter libwebp.Iterator
+	ok, err := libwebp.WebPDemuxGetFrame(dmux, 1, &iter)
+	if err != nil {
+		return err
+	}
+	defer libwebp.WebPDemuxReleaseIterator(&iter)
+	for i := range frames {
+		if i > 0 {
+			ok, err = libwebp.WebPDemuxNextFrame(&iter)
+			if err != nil {
+				return err
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%w: frame %d missing from the container", libwebp.ErrInvalidData, i)
+		}
+		x, y := int(iter.XOffset), int(iter.YOffset)
+		frames[i].Bounds = image.Rect(x, y, x+int(iter.Width), y+int(iter.Height))
+		frames[i].Dispose = libwebp.DisposeMethod(iter.DisposeMethod)
+		frames[i].Blend = libwebp.BlendMethod(iter.BlendMethod)
+	}
+	return nil
+}
+
+// EncodeAll writes anim to w as an animated WebP, encoding every frame with
+// the EncodeOptions of opts like Encode; nil opts uses the defaults and
+// anim.LoopCount, so that a DecodeAll result round-trips unchanged. The
+// canvas size is that of the first frame; frames start at the sum of the
+// preceding delays. Every frame is validated before any is encoded.
+// EncodeAll requires libwebpmux.
+func EncodeAll(w io.Writer, anim *Animation, opts *AnimEncodeOptions) error {
+	if anim == nil || len(anim.Frames) == 0 {
+		return ErrInvalidAnimation
+	}
+	loopCount := anim.LoopCount
+	if opts != nil {
+		if opts.LoopCount < 0 || opts.LoopCount > math.MaxUint16 {
+			return fmt.Errorf("%w: LoopCount %d out of range [0, %d]", ErrInvalidOption, opts.LoopCount, math.MaxUint16)
+		}
+		loopCount = opts.LoopCount
+	} else if loopCount < 0 || loopCount > math.MaxUint16 {
+		return fmt.Errorf("%w: LoopCount %d out of range [0, %d]", ErrInvalidAnimation, loopCount, math.MaxUint16)
+	}
+	if anim.Frames[0].Image == nil {
+		return fmt.Errorf("%w: frame 0 has no image", ErrInvalidAnimation)
+	}
+	canvas := anim.Frames[0].Image.Bounds().Size()
+	if err := checkEncodeBounds(image.Rectangle{Max: canvas}); err != nil {
+		return err
+	}
+	for i, frame := range anim.Frames {
+		if frame.Image == nil {
+			return fmt.Errorf("%w: frame %d has no image", ErrInvalidAnimation, i)
+		}
+		if size := frame.Image.Bounds().Size(); size != canvas {
+			return fmt.Errorf("%w: frame %d is %v, canvas is %v", ErrInvalidAnimation, i, size, canvas)
+		}
+		if frame.Delay.Milliseconds() <= 0 {
+			return fmt.Errorf("%w: frame %d has delay %v, want at least 1ms", ErrInvalidAnimation, i, frame.Delay)
+		}
+	}
+	frameOpts := opts.encodeOptions()
+	config, err := frameOpts.config()
+	if err != nil {
+		return err
+	}
+
+	var options libwebp.AnimEncoderOptions
+	ok, err := libwebp.WebPAnimEncoderOptionsInit(&options)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return libwebp.ErrEncodeFailed
+	}
+	options.AnimParams.LoopCount = int32(loopCount)
+	if opts != nil && opts.BackgroundColor != nil {
+		options.AnimParams.BgColor = animBackground(opts.BackgroundColor)
+	}
+
+	enc, err := libwebp.WebPAnimEncoderNew(canvas.X, canvas.Y, &options)
+	if err != nil {
+		return err
+	}
+	defer libwebp.WebPAnimEncoderDelete(enc)
+
+	var timestamp int64
+	for _, frame := range anim.Frames {
+		err := withPicture(toNRGBA(frame.Image), func(pic *libwebp.Picture) error {
+			if err := frameOpts.preparePicture(pic); err != nil {
+				return err
+			}
+			return libwebp.WebPAnimEncoderAdd(enc, pic, int(timestamp), config)
+		})
+		if err != nil {
+			return err
+		}
+		timestamp += frame.Delay.Milliseconds()
+		if timestamp > math.MaxInt32 {
+			return fmt.Errorf("%w: total duration overflows", ErrInvalidAnimation)
+		}
+	}
+	if err := libwebp.WebPAnimEncoderAdd(enc, nil, int(timestamp), nil); err != nil {
+		return err
+	}
+
+	data, err := libwebp.WebPAnimEncoderAssemble(enc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// WalkFrames decodes the animation in data frame by frame and calls fn with
+// each fully composited canvas and its display duration. Only one frame is
+// held in memory at a time; the image passed to fn is not reused and may be
+// retained. Walking stops at the first error returned by fn, which is
+// returned as is.
+//
+// A still (non-animated) WebP is reported as a single frame with zero delay.
+// WalkFrames requires libwebpdemux.
+func WalkFrames(data []byte, fn func(index int, img image.Image, delay time.Duration) error) error {
+	return walkFrames(data, nil, fn)
+}
+
+// walkFrames implements WalkFrames, storing the animation info in info when
+// it is not nil.
+func walkFrames(data []byte, info *libwebp.AnimInfo, fn func(index int, img image.Image, delay time.Duration) error) error {
+	dec, err := libwebp.WebPAnimDecoderNew(data, nil)
+	if err != nil {
+		return err
+	}
+	defer libwebp.WebPAnimDecoderDelete(dec)
+
+	if info == nil {
+		info = new(libwebp.AnimInfo)
+	}
+	*info, err = libwebp.WebPAnimDecoderGetInfo(dec)
+	if err != nil {
+		return err
+	}
+	width, height := int(info.CanvasWidth), int(info.CanvasHeight)
+	stride, size, err := decodeNRGBALayout(width, height)
+	if err != nil {
+		return err
+	}
+	if size > maxDecodedImageBytes {
+		return errDecodedImageTooLarge
+	}
+
+	prev := 0
+	for index := 0; ; index++ {
+		more, err := libwebp.WebPAnimDecoderHasMoreFrames(dec)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+
+		img := &image.NRGBA{Pix: make([]byte, size), Stride: stride, Rect: image.Rect(0, 0, width, height)}
+		timestamp, err := libwebp.WebPAnimDecoderGetNextInto(dec, img.Pix)
+		if err != nil {
+			return err
+		}
+		delay := time.Duration(timestamp-prev) * time.Millisecond
+		prev = timestamp
+
+		if err := fn(index, img, delay); err != nil {
+			return err
+		}
+	}
+}
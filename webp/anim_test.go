@@ -0,0 +1,420 @@
+package webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func requireDemux(t testing.TB) {
+	t.Helper()
+	if !libwebp.DemuxAvailable() {
+		t.Skip("libwebpdemux not available")
+	}
+}
+
+func requireMux(t testing.TB) {
+	t.Helper()
+	if !libwebp.MuxAvailable() {
+		t.Skip("libwebpmux not available")
+	}
+}
+
+func appendUint24(b []byte, v int) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16))
+}
+
+// animatedWebP assembles an animated WebP container in pure Go from
+// losslessly encoded, full-canvas frames.
+func animatedWebP(t testing.TB, frames []image.Image, durations []int, loopCount int) []byte {
+	t.Helper()
+	subFrames := make([]testSubFrame, len(frames))
+	for i, frame := range frames {
+		subFrames[i] = testSubFrame{img: frame, duration: durations[i], blend: libwebp.BlendNone}
+	}
+	return animatedWebPSubFrames(t, frames[0].Bounds().Size(), subFrames, loopCount)
+}
+
+// testSubFrame is one ANMF chunk: img is placed at offset, which must be
+// even, on the canvas.
+type testSubFrame struct {
+	img      image.Image
+	offset   image.Point
+	duration int
+	dispose  libwebp.DisposeMethod
+	blend    libwebp.BlendMethod
+}
+
+// animatedWebPSubFrames assembles an animated WebP container in pure Go from
+// losslessly encoded sub-frames.
+func animatedWebPSubFrames(t testing.TB, canvas image.Point, frames []testSubFrame, loopCount int) []byte {
+	t.Helper()
+	vp8x := []byte{0x02 | 0x10, 0, 0, 0}
+	vp8x = appendUint24(vp8x, canvas.X-1)
+	vp8x = appendUint24(vp8x, canvas.Y-1)
+	anim := binary.LittleEndian.AppendUint32(nil, 0xffffffff)
+	anim = binary.LittleEndian.AppendUint16(anim, uint16(loopCount))
+	chunks := [][]byte{riffChunk("VP8X", vp8x), riffChunk("ANIM", anim)}
+
+	for i, frame := range frames {
+		enc, err := encodeLosslessBytes(frame.img)
+		if err != nil {
+			t.Fatalf("encode frame %d: %v", i, err)
+		}
+		size := frame.img.Bounds().Size()
+		anmf := appendUint24(nil, frame.offset.X/2)
+		anmf = appendUint24(anmf, frame.offset.Y/2)
+		anmf = appendUint24(anmf, size.X-1)
+		anmf = appendUint24(anmf, size.Y-1)
+		anmf = appendUint24(anmf, frame.duration)
+		anmf = append(anmf, byte(frame.blend)<<1|byte(frame.dispose))
+		anmf = append(anmf, enc[riffHeaderSize:]...)
+		chunks = append(chunks, riffChunk("ANMF", anmf))
+	}
+	return riffContainer(chunks...)
+}
+
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func testAnimation(t testing.TB) ([]byte, []color.NRGBA) {
+	colors := []color.NRGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	frames := make([]image.Image, len(colors))
+	for i, c := range colors {
+		frames[i] = solidNRGBA(8, 6, c)
+	}
+	return animatedWebP(t, frames, []int{100, 200, 300}, 3), colors
+}
+
+func TestWalkFrames(t *testing.T) {
+	requireDemux(t)
+	data, colors := testAnimation(t)
+
+	var got int
+	err := WalkFrames(data, func(index int, img image.Image, delay time.Duration) error {
+		if index != got {
+			t.Fatalf("index = %d, want %d", index, got)
+		}
+		if img.Bounds() != image.Rect(0, 0, 8, 6) {
+			t.Fatalf("frame %d bounds = %v", index, img.Bounds())
+		}
+		if c := img.(*image.NRGBA).NRGBAAt(4, 3); c != colors[index] {
+			t.Fatalf("frame %d color = %v, want %v", index, c, colors[index])
+		}
+		if want := time.Duration(100*(index+1)) * time.Millisecond; delay != want {
+			t.Fatalf("frame %d delay = %v, want %v", index, delay, want)
+		}
+		got++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkFrames() error = %v", err)
+	}
+	if got != len(colors) {
+		t.Fatalf("WalkFrames() visited %d frames, want %d", got, len(colors))
+	}
+}
+
+func TestWalkFramesStopsOnError(t *testing.T) {
+	requireDemux(t)
+	data, _ := testAnimation(t)
+
+	stop := errors.New("stop")
+	calls := 0
+	err := WalkFrames(data, func(int, image.Image, time.Duration) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("WalkFrames() = (%v, %d calls), want (%v, 1 call)", err, calls, stop)
+	}
+}
+
+func TestAnimDecoderReset(t *testing.T) {
+	requireDemux(t)
+	data, _ := testAnimation(t)
+
+	dec, err := libwebp.WebPAnimDecoderNew(data, nil)
+	if err != nil {
+		t.Fatalf("WebPAnimDecoderNew() error = %v", err)
+	}
+	defer libwebp.WebPAnimDecoderDelete(dec)
+
+	for _, want := range []int{100, 300} {
+		if _, ts, err := libwebp.WebPAnimDecoderGetNext(dec); err != nil || ts != want {
+			t.Fatalf("WebPAnimDecoderGetNext() = (%d, %v), want timestamp %d", ts, err, want)
+		}
+	}
+	if err := libwebp.WebPAnimDecoderReset(dec); err != nil {
+		t.Fatalf("WebPAnimDecoderReset() error = %v", err)
+	}
+	if more, err := libwebp.WebPAnimDecoderHasMoreFrames(dec); err != nil || !more {
+		t.Fatalf("WebPAnimDecoderHasMoreFrames() after reset = (%v, %v), want true", more, err)
+	}
+	if _, ts, err := libwebp.WebPAnimDecoderGetNext(dec); err != nil || ts != 100 {
+		t.Fatalf("WebPAnimDecoderGetNext() after reset = (%d, %v), want timestamp 100", ts, err)
+	}
+	if err := libwebp.WebPAnimDecoderReset(0); !errors.Is(err, libwebp.ErrInvalidData) {
+		t.Fatalf("WebPAnimDecoderReset(0) error = %v, want %v", err, libwebp.ErrInvalidData)
+	}
+}
+
+func TestAnimDecoderInfo(t *testing.T) {
+	requireDemux(t)
+	data, colors := testAnimation(t)
+
+	var options libwebp.AnimDecoderOptions
+	if ok, err := libwebp.WebPAnimDecoderOptionsInit(&options); err != nil || !ok {
+		t.Fatalf("WebPAnimDecoderOptionsInit() = (%v, %v)", ok, err)
+	}
+	if options.ColorMode != int32(libwebp.ModeRGBA) {
+		t.Fatalf("default ColorMode = %d, want %d", options.ColorMode, libwebp.ModeRGBA)
+	}
+
+	dec, err := libwebp.WebPAnimDecoderNew(data, &options)
+	if err != nil {
+		t.Fatalf("WebPAnimDecoderNew() error = %v", err)
+	}
+	defer libwebp.WebPAnimDecoderDelete(dec)
+
+	info, err := libwebp.WebPAnimDecoderGetInfo(dec)
+	if err != nil {
+		t.Fatalf("WebPAnimDecoderGetInfo() error = %v", err)
+	}
+	if info.CanvasWidth != 8 || info.CanvasHeight != 6 {
+		t.Fatalf("canvas = %dx%d, want 8x6", info.CanvasWidth, info.CanvasHeight)
+	}
+	if info.FrameCount != uint32(len(colors)) {
+		t.Fatalf("FrameCount = %d, want %d", info.FrameCount, len(colors))
+	}
+	if info.LoopCount != 3 {
+		t.Fatalf("LoopCount = %d, want 3", info.LoopCount)
+	}
+}
+
+func TestAnimEncoderAssemble(t *testing.T) {
+	requireMux(t)
+	requireDemux(t)
+
+	var options libwebp.AnimEncoderOptions
+	if ok, err := libwebp.WebPAnimEncoderOptionsInit(&options); err != nil || !ok {
+		t.Fatalf("WebPAnimEncoderOptionsInit() = (%v, %v)", ok, err)
+	}
+	options.AnimParams.LoopCount = 2
+	enc, err := libwebp.WebPAnimEncoderNew(8, 6, &options)
+	if err != nil {
+		t.Fatalf("WebPAnimEncoderNew() error = %v", err)
+	}
+	defer libwebp.WebPAnimEncoderDelete(enc)
+
+	config, err := (&EncodeOptions{Lossless: true}).config()
+	if err != nil {
+		t.Fatalf("config() error = %v", err)
+	}
+	colors := []color.NRGBA{{R: 255, A: 255}, {B: 255, A: 255}}
+	for i, c := range colors {
+		err := withPicture(solidNRGBA(8, 6, c), func(pic *libwebp.Picture) error {
+			return libwebp.WebPAnimEncoderAdd(enc, pic, i*150, config)
+		})
+		if err != nil {
+			t.Fatalf("WebPAnimEncoderAdd(frame %d) error = %v", i, err)
+		}
+	}
+	if err := libwebp.WebPAnimEncoderAdd(enc, nil, 300, nil); err != nil {
+		t.Fatalf("WebPAnimEncoderAdd(nil) error = %v", err)
+	}
+	data, err := libwebp.WebPAnimEncoderAssemble(enc)
+	if err != nil {
+		t.Fatalf("WebPAnimEncoderAssemble() error = %v", err)
+	}
+
+	var got []color.NRGBA
+	err = WalkFrames(data, func(index int, img image.Image, delay time.Duration) error {
+		if delay != 150*time.Millisecond {
+			t.Fatalf("frame %d delay = %v, want 150ms", index, delay)
+		}
+		got = append(got, img.(*image.NRGBA).NRGBAAt(4, 3))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkFrames() error = %v", err)
+	}
+	if len(got) != len(colors) || got[0] != colors[0] || got[1] != colors[1] {
+		t.Fatalf("decoded frames = %v, want %v", got, colors)
+	}
+}
+
+func TestEncodeAllDecodeAllRoundTrip(t *testing.T) {
+	requireMux(t)
+	requireDemux(t)
+
+	colors := []color.NRGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 128}}
+	delays := []time.Duration{80 * time.Millisecond, 120 * time.Millisecond, 250 * time.Millisecond}
+	anim := new(Animation)
+	for i, c := range colors {
+		anim.Frames = append(anim.Frames, Frame{Image: solidNRGBA(10, 7, c), Delay: delays[i]})
+	}
+
+	var buf bytes.Buffer
+	opts := &AnimEncodeOptions{
+		EncodeOptions:   EncodeOptions{Lossless: true},
+		LoopCount:       3,
+		BackgroundColor: color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff},
+	}
+	if err := EncodeAll(&buf, anim, opts); err != nil {
+		t.Fatalf("EncodeAll() error = %v", err)
+	}
+
+	dec, err := libwebp.WebPAnimDecoderNew(buf.Bytes(), nil)
+	if err != nil {
+		t.Fatalf("WebPAnimDecoderNew() error = %v", err)
+	}
+	info, err := libwebp.WebPAnimDecoderGetInfo(dec)
+	libwebp.WebPAnimDecoderDelete(dec)
+	if err != nil {
+		t.Fatalf("WebPAnimDecoderGetInfo() error = %v", err)
+	}
+	if want := uint32(0x302010ff); info.BgColor != want {
+		t.Fatalf("BgColor = %#08x, want %#08x", info.BgColor, want)
+	}
+
+	got, err := DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("DecodeAll() error = %v", err)
+	}
+	if len(got.Frames) != len(colors) {
+		t.Fatalf("DecodeAll() frames = %d, want %d", len(got.Frames), len(colors))
+	}
+	if got.LoopCount != opts.LoopCount {
+		t.Fatalf("LoopCount = %d, want %d", got.LoopCount, opts.LoopCount)
+	}
+	for i, frame := range got.Frames {
+		if frame.Delay != delays[i] {
+			t.Fatalf("frame %d delay = %v, want %v", i, frame.Delay, delays[i])
+		}
+		if c := frame.Image.(*image.NRGBA).NRGBAAt(5, 3); c != colors[i] {
+			t.Fatalf("frame %d color = %v, want %v", i, c, colors[i])
+		}
+	}
+
+	// Without options the decoded animation keeps its own loop count.
+	buf.Reset()
+	if err := EncodeAll(&buf, got, nil); err != nil {
+		t.Fatalf("EncodeAll(decoded) error = %v", err)
+	}
+	again, err := DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("DecodeAll(re-encoded) error = %v", err)
+	}
+	if again.LoopCount != got.LoopCount {
+		t.Fatalf("re-encoded LoopCount = %d, want %d", again.LoopCount, got.LoopCount)
+	}
+}
+
+func TestEncodeAllRejectsInvalidAnimation(t *testing.T) {
+	img := solidNRGBA(2, 2, color.NRGBA{A: 255})
+	for _, anim := range []*Animation{
+		nil,
+		{},
+		{Frames: []Frame{{Image: img, Delay: time.Second}}, LoopCount: -1},
+		{Frames: []Frame{{Delay: time.Second}}},
+		{Frames: []Frame{{Image: img, Delay: time.Second}, {Delay: time.Second}}},
+		{Frames: []Frame{{Image: img}}},
+		{Frames: []Frame{{Image: img, Delay: time.Second}, {Image: img, Delay: time.Microsecond}}},
+	} {
+		if err := EncodeAll(&bytes.Buffer{}, anim, nil); !errors.Is(err, ErrInvalidAnimation) {
+			t.Fatalf("EncodeAll(%+v) error = %v, want %v", anim, err, ErrInvalidAnimation)
+		}
+	}
+	anim := &Animation{Frames: []Frame{{Image: img, Delay: time.Second}}}
+	for _, loops := range []int{-1, 1 << 16} {
+		if err := EncodeAll(&bytes.Buffer{}, anim, &AnimEncodeOptions{LoopCount: loops}); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("EncodeAll(LoopCount %d) error = %v, want %v", loops, err, ErrInvalidOption)
+		}
+	}
+}
+
+func TestDecodeAllDisposeAndBlend(t *testing.T) {
+	requireDemux(t)
+	red := color.NRGBA{R: 255, A: 255}
+	green := color.NRGBA{G: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	var transparent color.NRGBA
+
+	// The second frame is green on its left half and transparent on its
+	// right half: blended, the red underneath shows through.
+	half := solidNRGBA(4, 2, green)
+	draw.Draw(half, image.Rect(2, 0, 4, 2), image.Transparent, image.Point{}, draw.Src)
+	data := animatedWebPSubFrames(t, image.Pt(8, 6), []testSubFrame{
+		{img: solidNRGBA(8, 6, red), duration: 100, blend: libwebp.BlendNone},
+		{img: half, offset: image.Pt(2, 2), duration: 100, dispose: libwebp.DisposeBackground, blend: libwebp.BlendAlpha},
+		{img: solidNRGBA(2, 2, blue), duration: 100, blend: libwebp.BlendNone},
+	}, 0)
+
+	anim, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeAll() error = %v", err)
+	}
+	if len(anim.Frames) != 3 {
+		t.Fatalf("DecodeAll() frames = %d, want 3", len(anim.Frames))
+	}
+
+	wantSub := []struct {
+		bounds  image.Rectangle
+		dispose libwebp.DisposeMethod
+		blend   libwebp.BlendMethod
+	}{
+		{image.Rect(0, 0, 8, 6), libwebp.DisposeNone, libwebp.BlendNone},
+		{image.Rect(2, 2, 6, 4), libwebp.DisposeBackground, libwebp.BlendAlpha},
+		{image.Rect(0, 0, 2, 2), libwebp.DisposeNone, libwebp.BlendNone},
+	}
+	for i, want := range wantSub {
+		f := anim.Frames[i]
+		if f.Bounds != want.bounds || f.Dispose != want.dispose || f.Blend != want.blend {
+			t.Errorf("frame %d sub-frame = %v dispose %d blend %d, want %v dispose %d blend %d",
+				i, f.Bounds, f.Dispose, f.Blend, want.bounds, want.dispose, want.blend)
+		}
+	}
+
+	// Reference canvases: each maps a rectangle to its expected color,
+	// later entries overriding earlier ones.
+	type region struct {
+		r image.Rectangle
+		c color.NRGBA
+	}
+	canvas := image.Rect(0, 0, 8, 6)
+	refs := [][]region{
+		{{canvas, red}},
+		{{canvas, red}, {image.Rect(2, 2, 4, 4), green}},
+		// The second frame was disposed to background before the third.
+		{{canvas, red}, {image.Rect(2, 2, 6, 4), transparent}, {image.Rect(0, 0, 2, 2), blue}},
+	}
+	for i, ref := range refs {
+		want := image.NewNRGBA(canvas)
+		for _, reg := range ref {
+			draw.Draw(want, reg.r, image.NewUniform(reg.c), image.Point{}, draw.Src)
+		}
+		got := anim.Frames[i].Image.(*image.NRGBA)
+		for y := range 6 {
+			for x := range 8 {
+				if g, w := got.NRGBAAt(x, y), want.NRGBAAt(x, y); g != w {
+					t.Fatalf("frame %d pixel (%d, %d) = %v, want %v", i, x, y, g, w)
+				}
+			}
+		}
+	}
+}
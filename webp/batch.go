@@ -0,0 +1,50 @@
+package webp
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DecodeBatch decodes every input concurrently on at most workers
+// goroutines (GOMAXPROCS when workers <= 0) and returns the images and
+// errors in input order: images[i] is nil exactly when errs[i] is not.
+//
+// Once ctx is done, inputs not yet started are skipped with ctx.Err() as
+// their error; decodes already running finish. DecodeBatch returns only
+// after all of its goroutines have exited.
+func DecodeBatch(ctx context.Context, inputs [][]byte, workers int) ([]image.Image, []error) {
+	images := make([]image.Image, len(inputs))
+	errs := make([]error, len(inputs))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(inputs))
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(inputs) {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				img, err := decodeNRGBA(inputs[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				images[i] = img
+			}
+		})
+	}
+	wg.Wait()
+	return images, errs
+}
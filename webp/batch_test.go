@@ -0,0 +1,62 @@
+package webp
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func TestDecodeBatchPreservesOrder(t *testing.T) {
+	inputs := make([][]byte, 100)
+	for i := range inputs {
+		data, err := encodeLosslessBytes(solidNRGBA(1+i%7, 1+i%5, color.NRGBA{R: uint8(i), G: 255 - uint8(i), A: 255}))
+		if err != nil {
+			t.Fatalf("encode fixture %d: %v", i, err)
+		}
+		inputs[i] = data
+	}
+
+	images, errs := DecodeBatch(context.Background(), inputs, 8)
+	if len(images) != len(inputs) || len(errs) != len(inputs) {
+		t.Fatalf("DecodeBatch() returned %d images, %d errors, want %d", len(images), len(errs), len(inputs))
+	}
+	for i, img := range images {
+		if errs[i] != nil {
+			t.Fatalf("input %d error = %v", i, errs[i])
+		}
+		if want := image.Rect(0, 0, 1+i%7, 1+i%5); img.Bounds() != want {
+			t.Fatalf("input %d bounds = %v, want %v", i, img.Bounds(), want)
+		}
+		if c := img.(*image.NRGBA).NRGBAAt(0, 0); c != (color.NRGBA{R: uint8(i), G: 255 - uint8(i), A: 255}) {
+			t.Fatalf("input %d color = %v", i, c)
+		}
+	}
+}
+
+func TestDecodeBatchErrors(t *testing.T) {
+	good, err := encodeLosslessBytes(solidNRGBA(2, 2, color.NRGBA{A: 255}))
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	images, errs := DecodeBatch(context.Background(), [][]byte{good, []byte("bad"), good}, 0)
+	if errs[0] != nil || errs[2] != nil || images[0] == nil || images[2] == nil {
+		t.Fatalf("valid inputs: errs = %v", errs)
+	}
+	if !errors.Is(errs[1], libwebp.ErrInvalidData) || images[1] != nil {
+		t.Fatalf("invalid input: (%v, %v), want (nil, %v)", images[1], errs[1], libwebp.ErrInvalidData)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	images, errs = DecodeBatch(ctx, [][]byte{good, good}, 2)
+	for i := range errs {
+		if !errors.Is(errs[i], context.Canceled) || images[i] != nil {
+			t.Fatalf("canceled input %d: (%v, %v), want (nil, %v)", i, images[i], errs[i], context.Canceled)
+		}
+	}
+}
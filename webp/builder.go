@@ -0,0 +1,137 @@
+package webp
+
+import (
+	"fmt"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// ConfigBuilder assembles a libwebp encoder config for advanced callers who
+// need fields EncodeOptions does not expose. Setters chain and only record
+// the value; Build starts from the libwebp defaults (WebPConfigInit), applies
+// them in call order and validates the result. The zero value is ready to
+// use.
+//
+//	config, err := webp.NewConfigBuilder().Quality(90).Method(6).SharpYuv(true).Build()
+type ConfigBuilder struct {
+	edits []func(*libwebp.Config)
+	err   error
+}
+
+// NewConfigBuilder returns an empty ConfigBuilder.
+func NewConfigBuilder() *ConfigBuilder {
+	return new(ConfigBuilder)
+}
+
+func (b *ConfigBuilder) set(edit func(*libwebp.Config)) *ConfigBuilder {
+	b.edits = append(b.edits, edit)
+	return b
+}
+
+// Quality sets the lossy quality, or the lossless effort, in [0, 100].
+func (b *ConfigBuilder) Quality(q float32) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.Quality = q })
+}
+
+// Method sets the speed/size trade-off in [0, 6]; higher is slower and
+// smaller.
+func (b *ConfigBuilder) Method(m int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.Method = int32(m) })
+}
+
+// Lossless selects lossless encoding.
+func (b *ConfigBuilder) Lossless(lossless bool) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.Lossless = boolInt32(lossless) })
+}
+
+// NearLossless sets the near-lossless preprocessing level in [0, 100]; 100
+// disables it. It only affects lossless encoding.
+func (b *ConfigBuilder) NearLossless(level int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.NearLossless = int32(level) })
+}
+
+// Exact preserves the RGB values under fully transparent pixels.
+func (b *ConfigBuilder) Exact(exact bool) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.Exact = boolInt32(exact) })
+}
+
+// TargetSize makes the lossy encoder aim for an output of size bytes,
+// overriding Quality; 0 disables it.
+func (b *ConfigBuilder) TargetSize(size int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.TargetSize = int32(size) })
+}
+
+// TargetPSNR makes the lossy encoder aim for a PSNR in dB, overriding
+// Quality; 0 disables it.
+func (b *ConfigBuilder) TargetPSNR(psnr float32) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.TargetPSNR = psnr })
+}
+
+// Pass sets the number of entropy-analysis passes in [1, 10] used with
+// TargetSize or TargetPSNR.
+func (b *ConfigBuilder) Pass(n int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.Pass = int32(n) })
+}
+
+// SharpYuv uses the slower, more accurate RGB->YUV conversion.
+func (b *ConfigBuilder) SharpYuv(sharp bool) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.UseSharpYuv = boolInt32(sharp) })
+}
+
+// AlphaQuality sets the lossy alpha plane quality in [0, 100].
+func (b *ConfigBuilder) AlphaQuality(q int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.AlphaQuality = int32(q) })
+}
+
+// FilterStrength sets the deblocking filter strength in [0, 100].
+func (b *ConfigBuilder) FilterStrength(strength int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.FilterStrength = int32(strength) })
+}
+
+// Segments sets the number of lossy segments in [1, 4].
+func (b *ConfigBuilder) Segments(n int) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.Segments = int32(n) })
+}
+
+// ImageHint tells the lossless encoder what kind of image it compresses.
+func (b *ConfigBuilder) ImageHint(hint libwebp.Hint) *ConfigBuilder {
+	if !hint.Valid() && b.err == nil {
+		b.err = fmt.Errorf("%w: ImageHint %d", libwebp.ErrInvalidEnum, hint)
+	}
+	return b.set(func(c *libwebp.Config) { c.ImageHint = int32(hint) })
+}
+
+// Multithreaded lets libwebp use extra threads where it can.
+func (b *ConfigBuilder) Multithreaded(threads bool) *ConfigBuilder {
+	return b.set(func(c *libwebp.Config) { c.ThreadLevel = boolInt32(threads) })
+}
+
+// Build returns a new config holding the libwebp defaults with every setter
+// applied. A config libwebp rejects returns libwebp.ErrInvalidConfig.
+func (b *ConfigBuilder) Build() (*libwebp.Config, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	config := new(libwebp.Config)
+	ok, err := libwebp.WebPConfigInit(config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrEncodeFailed
+	}
+	for _, edit := range b.edits {
+		edit(config)
+	}
+	if err := libwebp.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func boolInt32(v bool) int32 {
+	if v {
+		return 1
+	}
+	return 0
+}
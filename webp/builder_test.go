@@ -0,0 +1,46 @@
+package webp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	config, err := NewConfigBuilder().
+		Quality(90).
+		Method(6).
+		Lossless(true).
+		NearLossless(60).
+		Exact(true).
+		ImageHint(libwebp.HintGraph).
+		Lossless(false).
+		TargetSize(4096).
+		Pass(4).
+		SharpYuv(true).
+		AlphaQuality(80).
+		Segments(2).
+		Multithreaded(true).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if err := libwebp.ValidateConfig(config); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	want := *config
+	want.Quality, want.Method, want.Lossless, want.NearLossless, want.Exact = 90, 6, 0, 60, 1
+	want.ImageHint, want.TargetSize, want.Pass, want.UseSharpYuv = int32(libwebp.HintGraph), 4096, 4, 1
+	want.AlphaQuality, want.Segments, want.ThreadLevel = 80, 2, 1
+	if *config != want {
+		t.Fatalf("Build() = %+v, want %+v", *config, want)
+	}
+
+	if _, err := NewConfigBuilder().Method(7).Build(); !errors.Is(err, libwebp.ErrInvalidConfig) {
+		t.Fatalf("Build(Method 7) error = %v, want %v", err, libwebp.ErrInvalidConfig)
+	}
+	if _, err := NewConfigBuilder().ImageHint(libwebp.HintLast).Build(); !errors.Is(err, libwebp.ErrInvalidEnum) {
+		t.Fatalf("Build(HintLast) error = %v, want %v", err, libwebp.ErrInvalidEnum)
+	}
+}
@@ -0,0 +1,190 @@
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+const (
+	riffHeaderSize  = 12
+	chunkHeaderSize = 8
+)
+
+// ErrInvalidFourCC indicates a chunk identifier that is not exactly 4 bytes.
+var ErrInvalidFourCC = errors.New("webp: FourCC must be exactly 4 bytes")
+
+// firstChunk validates the RIFF/WEBP container header and returns the FourCC
+// of the first chunk, which identifies the simple (VP8/VP8L) or extended
+// (VP8X) format. No library load is needed.
+func firstChunk(data []byte) (string, error) {
+	if len(data) < riffHeaderSize+chunkHeaderSize ||
+		string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return "", libwebp.ErrInvalidData
+	}
+	if binary.LittleEndian.Uint32(data[4:8]) < 4+chunkHeaderSize {
+		return "", libwebp.ErrInvalidData
+	}
+
+	fourcc := string(data[12:16])
+	switch fourcc {
+	case "VP8 ", "VP8L", "VP8X":
+		return fourcc, nil
+	default:
+		return "", libwebp.ErrInvalidData
+	}
+}
+
+// IsWebP reports whether data starts with a WebP container: a RIFF header
+// with the WEBP form type followed by a VP8, VP8L or VP8X chunk. It is a cheap
+// signature check done in pure Go, without loading libwebp; data may still
+// fail to decode.
+func IsWebP(data []byte) bool {
+	_, err := firstChunk(data)
+	return err == nil
+}
+
+// IsExtendedFormat reports whether data uses the extended (VP8X) container
+// layout required for alpha with lossy data, animation, and metadata, rather
+// than the simple lossy (VP8) or lossless (VP8L) form. It parses the RIFF
+// header in pure Go and returns an error if data is not a WebP container.
+func IsExtendedFormat(data []byte) (bool, error) {
+	fourcc, err := firstChunk(data)
+	if err != nil {
+		return false, err
+	}
+	return fourcc == "VP8X", nil
+}
+
+// IsAnimated reports whether data is an animated WebP, reading the animation
+// flag of the VP8X header in pure Go. Simple lossy and lossless files are
+// never animated. If the VP8X payload is cut short before its flags, the
+// answer comes from libwebp's WebPGetFeatures instead.
+func IsAnimated(data []byte) (bool, error) {
+	fourcc, err := firstChunk(data)
+	if err != nil {
+		return false, err
+	}
+	if fourcc != "VP8X" {
+		return false, nil
+	}
+	if flags := riffHeaderSize + chunkHeaderSize; len(data) > flags {
+		return uint32(data[flags])&libwebp.FlagAnimation != 0, nil
+	}
+
+	features, status, err := libwebp.WebPGetFeatures(data)
+	if err != nil {
+		return false, err
+	}
+	if err := libwebp.ErrorFromStatus(status); err != nil {
+		return false, err
+	}
+	return features.HasAnimation, nil
+}
+
+// ListChunks returns the FourCCs of the top-level chunks of the WebP container
+// in data, in file order; frames nested in ANMF chunks are not listed. The
+// RIFF structure is walked in pure Go since libwebpdemux offers no chunk
+// enumeration, so no library load is needed.
+func ListChunks(data []byte) ([]string, error) {
+	if _, err := firstChunk(data); err != nil {
+		return nil, err
+	}
+	// The RIFF size counts the bytes after the size field.
+	end := min(8+int(binary.LittleEndian.Uint32(data[4:8])), len(data))
+
+	var chunks []string
+	for off := riffHeaderSize; off < end; {
+		if end-off < chunkHeaderSize {
+			return nil, libwebp.ErrInvalidData
+		}
+		size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+		if size > end-off-chunkHeaderSize {
+			return nil, libwebp.ErrInvalidData
+		}
+		chunks = append(chunks, string(data[off:off+4]))
+		off += chunkHeaderSize + size + size&1
+	}
+	return chunks, nil
+}
+
+// GetChunk returns a copy of the payload of the first chunk with the given
+// FourCC and whether it was found. Metadata (ICCP, EXIF, "XMP ") and unknown
+// auxiliary chunks can be read; image and animation chunks cannot. It
+// requires libwebpdemux.
+func GetChunk(data []byte, fourcc string) ([]byte, bool, error) {
+	if len(fourcc) != 4 {
+		return nil, false, ErrInvalidFourCC
+	}
+	return readChunk(data, fourcc)
+}
+
+// ContainerInfo describes the structure of a WebP container as reported by
+// Inspect.
+type ContainerInfo struct {
+	// Width and Height are the canvas size.
+	Width, Height int
+	// FrameCount is 1 for still images.
+	FrameCount int
+	// LoopCount is the animation loop count; 0 loops forever.
+	LoopCount int
+	// Flags holds the raw VP8X feature flags (libwebp.Flag*); it is 0 for
+	// the simple VP8/VP8L formats.
+	Flags uint32
+
+	HasAlpha     bool
+	HasAnimation bool
+	HasICC       bool
+	HasEXIF      bool
+	HasXMP       bool
+}
+
+// Inspect parses the WebP container in data without decoding any pixels.
+// HasAlpha also reflects the alpha channel of simple-format lossless images,
+// which carry no VP8X flags. Inspect requires libwebpdemux.
+func Inspect(data []byte) (*ContainerInfo, error) {
+	dmux, err := libwebp.WebPDemux(data)
+	if err != nil {
+		return nil, err
+	}
+	defer libwebp.WebPDemuxDelete(dmux)
+
+	var getErr error
+	get := func(feature int) int {
+		v, err := libwebp.WebPDemuxGetI(dmux, feature)
+		if err != nil && getErr == nil {
+			getErr = err
+		}
+		return int(v)
+	}
+	flags := uint32(get(libwebp.DemuxFormatFlags))
+	info := &ContainerInfo{
+		Width:        get(libwebp.DemuxCanvasWidth),
+		Height:       get(libwebp.DemuxCanvasHeight),
+		FrameCount:   get(libwebp.DemuxFrameCount),
+		LoopCount:    get(libwebp.DemuxLoopCount),
+		Flags:        flags,
+		HasAlpha:     flags&libwebp.FlagAlpha != 0,
+		HasAnimation: flags&libwebp.FlagAnimation != 0,
+		HasICC:       flags&libwebp.FlagICCP != 0,
+		HasEXIF:      flags&libwebp.FlagEXIF != 0,
+		HasXMP:       flags&libwebp.FlagXMP != 0,
+	}
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !info.HasAlpha {
+		var iter libwebp.Iterator
+		ok, err := libwebp.WebPDemuxGetFrame(dmux, 1, &iter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			info.HasAlpha = iter.HasAlpha != 0
+			libwebp.WebPDemuxReleaseIterator(&iter)
+		}
+	}
+	return info, nil
+}
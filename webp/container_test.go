@@ -0,0 +1,180 @@
+package webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func riffContainer(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+func riffChunk(fourcc string, payload []byte) []byte {
+	out := []byte(fourcc)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+	out = append(out, payload...)
+	if len(payload)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func TestIsWebP(t *testing.T) {
+	lossless, img := testWebP(t)
+	var lossy bytes.Buffer
+	if err := Encode(&lossy, img, &EncodeOptions{Quality: 75}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	extended := riffContainer(riffChunk("VP8X", make([]byte, 10)), lossless[riffHeaderSize:])
+
+	for name, data := range map[string][]byte{"lossy": lossy.Bytes(), "lossless": lossless, "extended": extended} {
+		if !IsWebP(data) {
+			t.Errorf("IsWebP(%s) = false, want true", name)
+		}
+	}
+
+	wave := append([]byte("RIFF"), lossless[4:]...)
+	copy(wave[8:12], "WAVE")
+	for name, data := range map[string][]byte{
+		"nil":       nil,
+		"png":       []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"truncated": lossless[:riffHeaderSize+4],
+		"wave":      wave,
+		"junk":      riffContainer(riffChunk("JUNK", nil)),
+	} {
+		if IsWebP(data) {
+			t.Errorf("IsWebP(%s) = true, want false", name)
+		}
+	}
+}
+
+func TestIsExtendedFormat(t *testing.T) {
+	lossless, _ := testWebP(t)
+	if ext, err := IsExtendedFormat(lossless); err != nil || ext {
+		t.Fatalf("IsExtendedFormat(lossless) = (%v, %v), want (false, nil)", ext, err)
+	}
+
+	extended := riffContainer(riffChunk("VP8X", make([]byte, 10)), lossless[riffHeaderSize:])
+	if ext, err := IsExtendedFormat(extended); err != nil || !ext {
+		t.Fatalf("IsExtendedFormat(VP8X) = (%v, %v), want (true, nil)", ext, err)
+	}
+
+	for _, data := range [][]byte{nil, []byte("not a webp file at all"), riffContainer(riffChunk("JUNK", nil))} {
+		if _, err := IsExtendedFormat(data); err == nil {
+			t.Fatalf("IsExtendedFormat(%q) succeeded", data)
+		}
+	}
+}
+
+func TestIsAnimated(t *testing.T) {
+	_, img := testWebP(t)
+	var lossy bytes.Buffer
+	if err := Encode(&lossy, img, &EncodeOptions{Quality: 75}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if anim, err := IsAnimated(lossy.Bytes()); err != nil || anim {
+		t.Fatalf("IsAnimated(lossy) = (%v, %v), want (false, nil)", anim, err)
+	}
+
+	animated, _ := testAnimation(t)
+	if anim, err := IsAnimated(animated); err != nil || !anim {
+		t.Fatalf("IsAnimated(animation) = (%v, %v), want (true, nil)", anim, err)
+	}
+
+	if _, err := IsAnimated(animated[:riffHeaderSize+chunkHeaderSize]); err == nil {
+		t.Fatal("IsAnimated(truncated VP8X) succeeded")
+	}
+	if _, err := IsAnimated([]byte("not a webp file at all")); err == nil {
+		t.Fatal("IsAnimated(garbage) succeeded")
+	}
+}
+
+func TestInspect(t *testing.T) {
+	requireDemux(t)
+
+	still, _ := testWebP(t)
+	info, err := Inspect(still)
+	if err != nil {
+		t.Fatalf("Inspect(still) error = %v", err)
+	}
+	// libwebpdemux reports a loop count of 1 for still images.
+	want := ContainerInfo{Width: 3, Height: 2, FrameCount: 1, LoopCount: 1, HasAlpha: true}
+	if *info != want {
+		t.Fatalf("Inspect(still) = %+v, want %+v", *info, want)
+	}
+
+	anim, _ := testAnimation(t)
+	info, err = Inspect(anim)
+	if err != nil {
+		t.Fatalf("Inspect(animation) error = %v", err)
+	}
+	if info.Width != 8 || info.Height != 6 || info.FrameCount != 3 || info.LoopCount != 3 {
+		t.Fatalf("Inspect(animation) = %+v, want 8x6, 3 frames, loop 3", *info)
+	}
+	if !info.HasAnimation || !info.HasAlpha || info.HasICC || info.HasEXIF || info.HasXMP {
+		t.Fatalf("Inspect(animation) flags = %+v", *info)
+	}
+
+	if _, err := Inspect([]byte("not a webp file at all")); err == nil {
+		t.Fatal("Inspect(garbage) succeeded")
+	}
+}
+
+// metadataWebP wraps the lossless fixture in an extended container carrying
+// the given ICCP and EXIF payloads.
+func metadataWebP(t testing.TB, icc, exif []byte) []byte {
+	t.Helper()
+	lossless, img := testWebP(t)
+	vp8x := []byte{0x20 | 0x10 | 0x08, 0, 0, 0}
+	vp8x = appendUint24(vp8x, img.Rect.Dx()-1)
+	vp8x = appendUint24(vp8x, img.Rect.Dy()-1)
+	return riffContainer(
+		riffChunk("VP8X", vp8x),
+		riffChunk("ICCP", icc),
+		lossless[riffHeaderSize:],
+		riffChunk("EXIF", exif),
+	)
+}
+
+func TestListChunks(t *testing.T) {
+	data := metadataWebP(t, []byte("icc"), []byte("exif data"))
+	got, err := ListChunks(data)
+	if err != nil {
+		t.Fatalf("ListChunks() error = %v", err)
+	}
+	want := []string{"VP8X", "ICCP", "VP8L", "EXIF"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ListChunks() = %q, want %q", got, want)
+	}
+
+	if _, err := ListChunks(data[:len(data)-3]); err == nil {
+		t.Fatal("ListChunks(truncated) succeeded")
+	}
+	if _, err := ListChunks([]byte("not a webp file at all")); err == nil {
+		t.Fatal("ListChunks(garbage) succeeded")
+	}
+}
+
+func TestGetChunk(t *testing.T) {
+	if _, _, err := GetChunk(nil, "XMP"); !errors.Is(err, ErrInvalidFourCC) {
+		t.Fatalf("GetChunk(3-byte FourCC) error = %v, want %v", err, ErrInvalidFourCC)
+	}
+	requireDemux(t)
+
+	data := metadataWebP(t, []byte("icc"), []byte("exif data"))
+	if got, ok, err := GetChunk(data, "EXIF"); err != nil || !ok || string(got) != "exif data" {
+		t.Fatalf(`GetChunk("EXIF") = (%q, %v, %v), want "exif data"`, got, ok, err)
+	}
+	if got, ok, err := GetChunk(data, "XMP "); err != nil || ok || got != nil {
+		t.Fatalf(`GetChunk("XMP ") = (%q, %v, %v), want not found`, got, ok, err)
+	}
+}
@@ -0,0 +1,89 @@
+package webp
+
+import (
+	"image"
+	"image/color"
+)
+
+// The converters below produce exactly what convertNRGBA would for their
+// source type, without the per-pixel interface calls.
+
+// rgbaToNRGBA un-premultiplies src.
+func rgbaToNRGBA(src *image.RGBA) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		s := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):][: w*4 : w*4]
+		d := dst.Pix[y*dst.Stride:][: w*4 : w*4]
+		for i := 0; i < len(s); i += 4 {
+			switch a := uint32(s[i+3]); a {
+			case 0xff:
+				copy(d[i:i+4], s[i:i+4])
+			case 0:
+				d[i], d[i+1], d[i+2], d[i+3] = 0, 0, 0, 0
+			default:
+				// Same rounding as color.NRGBAModel on the 16-bit values.
+				d[i] = uint8(uint32(s[i]) * 0xffff / a >> 8)
+				d[i+1] = uint8(uint32(s[i+1]) * 0xffff / a >> 8)
+				d[i+2] = uint8(uint32(s[i+2]) * 0xffff / a >> 8)
+				d[i+3] = uint8(a)
+			}
+		}
+	}
+	return dst
+}
+
+// grayToNRGBA replicates each gray sample into opaque R, G and B.
+func grayToNRGBA(src *image.Gray) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		s := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):][:w:w]
+		d := dst.Pix[y*dst.Stride:][: w*4 : w*4]
+		for x, v := range s {
+			d[x*4], d[x*4+1], d[x*4+2], d[x*4+3] = v, v, v, 0xff
+		}
+	}
+	return dst
+}
+
+// ycbcrToNRGBA converts src with color.YCbCrToRGB, honoring its chroma
+// subsampling.
+func ycbcrToNRGBA(src *image.YCbCr) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		d := dst.Pix[y*dst.Stride:][: w*4 : w*4]
+		for x := range w {
+			sx, sy := b.Min.X+x, b.Min.Y+y
+			r, g, bl := color.YCbCrToRGB(src.Y[src.YOffset(sx, sy)], src.Cb[src.COffset(sx, sy)], src.Cr[src.COffset(sx, sy)])
+			d[x*4], d[x*4+1], d[x*4+2], d[x*4+3] = r, g, bl, 0xff
+		}
+	}
+	return dst
+}
+
+// palettedToNRGBA converts the palette to NRGBA once and then looks each
+// index up in that table. Indices past the end of the palette, which make
+// src.At panic, become transparent black.
+func palettedToNRGBA(src *image.Paletted) *image.NRGBA {
+	var table [256][4]uint8
+	for i, c := range src.Palette[:min(len(src.Palette), 256)] {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		table[i] = [4]uint8{n.R, n.G, n.B, n.A}
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		s := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):][:w:w]
+		d := dst.Pix[y*dst.Stride:][: w*4 : w*4]
+		for x, v := range s {
+			copy(d[x*4:x*4+4], table[v][:])
+		}
+	}
+	return dst
+}
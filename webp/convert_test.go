@@ -0,0 +1,125 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func randomRGBA(rng *rand.Rand, r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(r)
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := uint8(rng.IntN(256))
+		switch rng.IntN(4) {
+		case 0:
+			a = 0
+		case 1:
+			a = 0xff
+		}
+		// Premultiplied: color channels never exceed alpha.
+		for c := range 3 {
+			img.Pix[i+c] = uint8(rng.IntN(int(a) + 1))
+		}
+		img.Pix[i+3] = a
+	}
+	return img
+}
+
+func randomGray(rng *rand.Rand, r image.Rectangle) *image.Gray {
+	img := image.NewGray(r)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.IntN(256))
+	}
+	return img
+}
+
+func randomYCbCr(rng *rand.Rand, r image.Rectangle, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+	img := image.NewYCbCr(r, ratio)
+	for _, plane := range [][]byte{img.Y, img.Cb, img.Cr} {
+		for i := range plane {
+			plane[i] = uint8(rng.IntN(256))
+		}
+	}
+	return img
+}
+
+func randomPaletted(rng *rand.Rand, r image.Rectangle) *image.Paletted {
+	palette := make(color.Palette, 200)
+	for i := range palette {
+		a := uint8(rng.IntN(256))
+		palette[i] = color.NRGBA{uint8(rng.IntN(256)), uint8(rng.IntN(256)), uint8(rng.IntN(256)), a}
+	}
+	img := image.NewPaletted(r, palette)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.IntN(len(palette)))
+	}
+	return img
+}
+
+func TestToNRGBAFastPathsMatchGeneric(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	// Odd sizes and offset origins, plus sub-images, exercise the stride
+	// and chroma offset handling.
+	rect := image.Rect(3, 5, 3+37, 5+21)
+	sub := image.Rect(6, 8, 31, 23)
+
+	cases := map[string]image.Image{
+		"RGBA":        randomRGBA(rng, rect),
+		"RGBASub":     randomRGBA(rng, rect).SubImage(sub),
+		"Gray":        randomGray(rng, rect),
+		"GraySub":     randomGray(rng, rect).SubImage(sub),
+		"Paletted":    randomPaletted(rng, rect),
+		"PalettedSub": randomPaletted(rng, rect).SubImage(sub),
+	}
+	for _, ratio := range []image.YCbCrSubsampleRatio{
+		image.YCbCrSubsampleRatio444, image.YCbCrSubsampleRatio422, image.YCbCrSubsampleRatio420,
+		image.YCbCrSubsampleRatio440, image.YCbCrSubsampleRatio411, image.YCbCrSubsampleRatio410,
+	} {
+		suffix := strings.TrimPrefix(ratio.String(), "YCbCrSubsampleRatio")
+		cases["YCbCr"+suffix] = randomYCbCr(rng, rect, ratio)
+		cases["YCbCrSub"+suffix] = randomYCbCr(rng, rect, ratio).SubImage(sub)
+	}
+
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, want := toNRGBA(src), convertNRGBA(src)
+			if got.Rect != want.Rect || got.Stride != want.Stride {
+				t.Fatalf("layout = %v stride %d, want %v stride %d", got.Rect, got.Stride, want.Rect, want.Stride)
+			}
+			if !bytes.Equal(got.Pix, want.Pix) {
+				for i := range got.Pix {
+					if got.Pix[i] != want.Pix[i] {
+						t.Fatalf("byte %d (pixel %d) = %d, want %d", i, i/4, got.Pix[i], want.Pix[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func benchmarkToNRGBA(b *testing.B, src image.Image, convert func(image.Image) *image.NRGBA) {
+	b.ReportAllocs()
+	for b.Loop() {
+		benchmarkDecodedImage = convert(src)
+	}
+}
+
+func BenchmarkToNRGBA(b *testing.B) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	r := image.Rect(0, 0, 512, 512)
+	for _, src := range []struct {
+		name string
+		img  image.Image
+	}{
+		{"RGBA", randomRGBA(rng, r)},
+		{"Gray", randomGray(rng, r)},
+		{"YCbCr420", randomYCbCr(rng, r, image.YCbCrSubsampleRatio420)},
+		{"Paletted", randomPaletted(rng, r)},
+	} {
+		b.Run(src.name+"/fast", func(b *testing.B) { benchmarkToNRGBA(b, src.img, toNRGBA) })
+		b.Run(src.name+"/generic", func(b *testing.B) { benchmarkToNRGBA(b, src.img, convertNRGBA) })
+	}
+}
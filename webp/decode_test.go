@@ -104,3 +104,95 @@ func BenchmarkDecode64x64(b *testing.B) {
 		}
 	}
 }
+
+func TestDecodeRGBAPremultiplied(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := range 16 {
+		for x := range 16 {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 16), G: 200, B: uint8(y * 16), A: uint8(x*y + 15)})
+		}
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	got, err := DecodeRGBA(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeRGBA() error = %v", err)
+	}
+	if got.Rect != src.Rect {
+		t.Fatalf("bounds = %v, want %v", got.Rect, src.Rect)
+	}
+	premul := func(c, a uint8) uint8 { return uint8((int(c)*int(a) + 127) / 255) }
+	for y := range 16 {
+		for x := range 16 {
+			s, g := src.NRGBAAt(x, y), got.RGBAAt(x, y)
+			want := color.RGBA{R: premul(s.R, s.A), G: premul(s.G, s.A), B: premul(s.B, s.A), A: s.A}
+			if g.A != want.A || diff(g.R, want.R) > 1 || diff(g.G, want.G) > 1 || diff(g.B, want.B) > 1 {
+				t.Fatalf("pixel (%d, %d) = %v, want %v ± 1", x, y, g, want)
+			}
+		}
+	}
+}
+
+func TestDecodeConfigColorModel(t *testing.T) {
+	opaque, err := encodeLosslessBytes(solidNRGBA(4, 3, color.NRGBA{R: 10, A: 255}))
+	if err != nil {
+		t.Fatalf("encode opaque fixture: %v", err)
+	}
+	transparent, err := encodeLosslessBytes(solidNRGBA(4, 3, color.NRGBA{R: 10, A: 128}))
+	if err != nil {
+		t.Fatalf("encode transparent fixture: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		data  []byte
+		model color.Model
+	}{
+		{"opaque", opaque, color.RGBAModel},
+		{"transparent", transparent, color.NRGBAModel},
+	} {
+		cfg, err := DecodeConfig(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Fatalf("%s: DecodeConfig() error = %v", tt.name, err)
+		}
+		if cfg.ColorModel != tt.model || cfg.Width != 4 || cfg.Height != 3 {
+			t.Fatalf("%s: DecodeConfig() = %+v, want 4x3 with %v", tt.name, cfg, tt.model)
+		}
+	}
+}
+
+func TestDecodeYCbCr(t *testing.T) {
+	// Odd dimensions exercise the (height+1)/2 chroma sizing.
+	const w, h = 15, 9
+	src := image.NewYCbCr(image.Rect(0, 0, w, h), image.YCbCrSubsampleRatio420)
+	for i := range src.Y {
+		src.Y[i] = 180
+	}
+	for i := range src.Cb {
+		src.Cb[i], src.Cr[i] = 90, 200
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, src, &EncodeOptions{Quality: 100}); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	got, err := DecodeYCbCr(&buf)
+	if err != nil {
+		t.Fatalf("DecodeYCbCr() error = %v", err)
+	}
+	if got.Rect != src.Rect || got.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+		t.Fatalf("DecodeYCbCr() = %v %v, want %v 4:2:0", got.Rect, got.SubsampleRatio, src.Rect)
+	}
+	if len(got.Cb) < got.CStride*(h+1)/2 {
+		t.Fatalf("Cb plane has %d bytes, want at least %d", len(got.Cb), got.CStride*(h+1)/2)
+	}
+	for _, p := range []image.Point{{0, 0}, {7, 4}, {w - 1, h - 1}} {
+		c := got.YCbCrAt(p.X, p.Y)
+		if diff(c.Y, 180) > 2 || diff(c.Cb, 90) > 2 || diff(c.Cr, 200) > 2 {
+			t.Errorf("sample at %v = %v, want about {180 90 200}", p, c)
+		}
+	}
+}
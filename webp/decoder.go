@@ -0,0 +1,48 @@
+package webp
+
+import (
+	"image"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// Decoder decodes WebP images into a scratch buffer that is reused across
+// calls, avoiding a pixel allocation per image once the buffer has grown to
+// the largest size seen. It suits services decoding many small images.
+//
+// A Decoder is not safe for concurrent use; use one per goroutine (for
+// example from a sync.Pool). The zero value is ready to use.
+type Decoder struct {
+	img image.NRGBA
+}
+
+// Decode decodes data into the Decoder's scratch image and returns it. The
+// returned image, including its Pix, is only valid until the next call to
+// Decode; copy it to keep it longer.
+func (d *Decoder) Decode(data []byte) (*image.NRGBA, error) {
+	w, h, ok, err := libwebp.WebPGetInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrInvalidData
+	}
+	stride, size, err := decodeNRGBALayout(w, h)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxDecodedImageBytes {
+		return nil, errDecodedImageTooLarge
+	}
+
+	if cap(d.img.Pix) < size {
+		d.img.Pix = make([]byte, size)
+	}
+	d.img.Pix = d.img.Pix[:size]
+	d.img.Stride = stride
+	d.img.Rect = image.Rect(0, 0, w, h)
+	if err := libwebp.WebPDecodeRGBAIntoWithInfo(data, d.img.Pix, stride, w, h); err != nil {
+		return nil, err
+	}
+	return &d.img, nil
+}
@@ -0,0 +1,62 @@
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func TestDecoderReusesBuffer(t *testing.T) {
+	var d Decoder
+
+	large, err := encodeLosslessBytes(solidNRGBA(16, 12, color.NRGBA{R: 200, A: 255}))
+	if err != nil {
+		t.Fatalf("encode large: %v", err)
+	}
+	small, err := encodeLosslessBytes(solidNRGBA(4, 3, color.NRGBA{G: 100, A: 255}))
+	if err != nil {
+		t.Fatalf("encode small: %v", err)
+	}
+
+	img, err := d.Decode(large)
+	if err != nil {
+		t.Fatalf("Decode(large) error = %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 16, 12) || img.NRGBAAt(15, 11) != (color.NRGBA{R: 200, A: 255}) {
+		t.Fatalf("Decode(large) = %v with %v", img.Bounds(), img.NRGBAAt(15, 11))
+	}
+	first := &img.Pix[0]
+
+	img, err = d.Decode(small)
+	if err != nil {
+		t.Fatalf("Decode(small) error = %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 4, 3) || img.Stride != 16 || len(img.Pix) != 48 {
+		t.Fatalf("Decode(small) = %v stride %d len %d", img.Bounds(), img.Stride, len(img.Pix))
+	}
+	if img.NRGBAAt(3, 2) != (color.NRGBA{G: 100, A: 255}) {
+		t.Fatalf("Decode(small) pixel = %v", img.NRGBAAt(3, 2))
+	}
+	if &img.Pix[0] != first {
+		t.Fatal("Decode(small) reallocated the scratch buffer")
+	}
+
+	want, err := Decode(bytes.NewReader(small))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !bytes.Equal(img.Pix, want.(*image.NRGBA).Pix) {
+		t.Fatal("Decoder.Decode and Decode disagree")
+	}
+}
+
+func TestDecoderRejectsInvalidData(t *testing.T) {
+	var d Decoder
+	if _, err := d.Decode([]byte("not a webp")); !errors.Is(err, libwebp.ErrInvalidData) {
+		t.Fatalf("Decode() error = %v, want %v", err, libwebp.ErrInvalidData)
+	}
+}
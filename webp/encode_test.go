@@ -0,0 +1,561 @@
+package webp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func TestEncodeRejectsImagesOverPixelLimit(t *testing.T) {
+	SetMaxEncodePixels(100)
+	defer SetMaxEncodePixels(0)
+
+	var buf bytes.Buffer
+	err := Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 11, 10)), nil)
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("Encode(11x10) error = %v, want %v", err, ErrImageTooLarge)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Encode(11x10) wrote %d bytes", buf.Len())
+	}
+	if err := Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 10, 10)), nil); err != nil {
+		t.Fatalf("Encode(10x10) error = %v", err)
+	}
+}
+
+func TestEncodeRejectsImagesOverMaxDimension(t *testing.T) {
+	for _, r := range []image.Rectangle{
+		image.Rect(0, 0, MaxDimension+1, 1),
+		image.Rect(0, 0, 1, MaxDimension+1),
+	} {
+		err := Encode(&bytes.Buffer{}, image.NewGray(r), nil)
+		if !errors.Is(err, ErrImageTooLarge) {
+			t.Fatalf("Encode(%v) error = %v, want %v", r, err, ErrImageTooLarge)
+		}
+	}
+}
+
+// transparentSprite returns an image with an opaque center and a wide fully
+// transparent border whose hidden RGB values are noise.
+func transparentSprite() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	seed := uint32(1)
+	for y := range 64 {
+		for x := range 64 {
+			seed = seed*1664525 + 1013904223
+			c := color.NRGBA{R: uint8(seed >> 24), G: uint8(seed >> 16), B: uint8(seed >> 8)}
+			if x >= 24 && x < 40 && y >= 24 && y < 40 {
+				c = color.NRGBA{R: 200, G: 40, B: 40, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// hiddenColors returns the number of distinct RGB values under the fully
+// transparent pixels of src after opts has prepared its picture.
+func hiddenColors(t *testing.T, src *image.NRGBA, opts *EncodeOptions) int {
+	t.Helper()
+	out := image.NewNRGBA(src.Rect)
+	err := withPicture(src, func(pic *libwebp.Picture) error {
+		if err := opts.preparePicture(pic); err != nil {
+			return err
+		}
+		return libwebp.PictureARGBToRGBA(pic, out.Pix, out.Stride)
+	})
+	if err != nil {
+		t.Fatalf("prepare picture with %+v: %v", *opts, err)
+	}
+	colors := map[color.NRGBA]bool{}
+	for i := 0; i < len(out.Pix); i += 4 {
+		if out.Pix[i+3] == 0 {
+			colors[color.NRGBA{R: out.Pix[i], G: out.Pix[i+1], B: out.Pix[i+2]}] = true
+		}
+	}
+	return len(colors)
+}
+
+func TestEncodeCleanupTransparentFlattensHiddenPixels(t *testing.T) {
+	src := transparentSprite()
+
+	if n := hiddenColors(t, src, &EncodeOptions{Quality: 90}); n < 1000 {
+		t.Fatalf("hidden colors without cleanup = %d, want the source noise", n)
+	}
+	if n := hiddenColors(t, src, &EncodeOptions{Quality: 90, CleanupTransparent: true}); n > 16 {
+		t.Fatalf("hidden colors with cleanup = %d, want the transparent area flattened", n)
+	}
+	if n := hiddenColors(t, src, &EncodeOptions{Quality: 90, CleanupTransparent: true, Exact: true}); n < 1000 {
+		t.Fatalf("hidden colors with cleanup and Exact = %d, want the source noise", n)
+	}
+
+	var cleaned bytes.Buffer
+	if err := Encode(&cleaned, src, &EncodeOptions{Lossless: true, CleanupTransparent: true}); err != nil {
+		t.Fatalf("Encode(cleanup) error = %v", err)
+	}
+	decoded, err := Decode(&cleaned)
+	if err != nil {
+		t.Fatalf("Decode(cleanup) error = %v", err)
+	}
+	if got := decoded.(*image.NRGBA).NRGBAAt(30, 30); got != src.NRGBAAt(30, 30) {
+		t.Fatalf("opaque pixel = %v, want %v", got, src.NRGBAAt(30, 30))
+	}
+}
+
+// cancelAfterCtx reports cancellation once Err has been polled n times.
+type cancelAfterCtx struct {
+	context.Context
+	n atomic.Int32
+}
+
+func (c *cancelAfterCtx) Err() error {
+	if c.n.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func noiseNRGBA(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(7)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint8(seed >> 24)
+	}
+	return img
+}
+
+func TestEncodeContextCancelMidEncode(t *testing.T) {
+	ctx := &cancelAfterCtx{Context: context.Background()}
+	ctx.n.Store(2)
+
+	var buf bytes.Buffer
+	err := EncodeContext(ctx, &buf, noiseNRGBA(256, 256), nil)
+	if !errors.Is(err, libwebp.ErrEncodeFailed) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("EncodeContext() error = %v, want %v and %v", err, libwebp.ErrEncodeFailed, context.Canceled)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("EncodeContext() wrote %d bytes after cancellation", buf.Len())
+	}
+
+	if err := EncodeContext(context.Background(), &buf, noiseNRGBA(64, 64), nil); err != nil {
+		t.Fatalf("EncodeContext(background) error = %v", err)
+	}
+	if _, err := Decode(&buf); err != nil {
+		t.Fatalf("Decode(EncodeContext output) error = %v", err)
+	}
+}
+
+func TestEncodeContextAlreadyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	err := EncodeContext(ctx, &buf, noiseNRGBA(64, 64), nil)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, libwebp.ErrEncodeFailed) {
+		t.Fatalf("EncodeContext(canceled) error = %v, want %v and %v", err, libwebp.ErrEncodeFailed, context.Canceled)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("EncodeContext(canceled) wrote %d bytes", buf.Len())
+	}
+}
+
+func TestEncodeContextCancelReturnsPromptly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := noiseNRGBA(1024, 1024)
+	errc := make(chan error, 1)
+	var buf bytes.Buffer
+	go func() { errc <- EncodeContext(ctx, &buf, src, &EncodeOptions{Method: new(6)}) }()
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("EncodeContext() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EncodeContext() did not return after cancellation")
+	}
+}
+
+func TestEncodeAlphaFiltering(t *testing.T) {
+	// Structured alpha: smooth horizontal and vertical ramps.
+	src := image.NewNRGBA(image.Rect(0, 0, 128, 128))
+	for y := range 128 {
+		for x := range 128 {
+			src.SetNRGBA(x, y, color.NRGBA{R: 80, G: 160, B: 240, A: uint8(x*2) ^ uint8(y/16)})
+		}
+	}
+
+	sizes := map[int]int{}
+	for _, filtering := range []int{0, 1, 2} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, src, &EncodeOptions{Quality: 80, AlphaFiltering: &filtering}); err != nil {
+			t.Fatalf("Encode(AlphaFiltering=%d) error = %v", filtering, err)
+		}
+		sizes[filtering] = buf.Len()
+	}
+	if sizes[0] == sizes[2] {
+		t.Fatalf("AlphaFiltering none and best produced the same size %d", sizes[0])
+	}
+
+	for _, bad := range []int{-1, 3} {
+		err := Encode(&bytes.Buffer{}, src, &EncodeOptions{AlphaFiltering: &bad})
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("Encode(AlphaFiltering=%d) error = %v, want %v", bad, err, ErrInvalidOption)
+		}
+	}
+}
+
+func TestEncodeRejectsInvalidQuality(t *testing.T) {
+	src := noiseNRGBA(4, 4)
+	for _, q := range []float32{-5, 150, float32(math.NaN())} {
+		err := Encode(&bytes.Buffer{}, src, &EncodeOptions{Quality: q})
+		if !errors.Is(err, ErrInvalidQuality) || !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("Encode(Quality=%v) error = %v, want %v", q, err, ErrInvalidQuality)
+		}
+	}
+	if err := Encode(&bytes.Buffer{}, src, &EncodeOptions{Quality: 100}); err != nil {
+		t.Fatalf("Encode(Quality=100) error = %v", err)
+	}
+}
+
+func TestEncodePartitionLimit(t *testing.T) {
+	opts := &EncodeOptions{Quality: 90, PartitionLimit: 50}
+	config, err := opts.config()
+	if err != nil {
+		t.Fatalf("config() error = %v", err)
+	}
+	if config.PartitionLimit != 50 {
+		t.Fatalf("PartitionLimit = %d, want 50", config.PartitionLimit)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, noiseNRGBA(64, 64), opts); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if img, err := Decode(&buf); err != nil || img.Bounds().Dx() != 64 {
+		t.Fatalf("Decode(PartitionLimit output) = (%v, %v)", img, err)
+	}
+
+	if err := Encode(&bytes.Buffer{}, noiseNRGBA(4, 4), &EncodeOptions{PartitionLimit: 101}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Encode(PartitionLimit=101) error = %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestEncodeEmulateJpegSize(t *testing.T) {
+	opts := &EncodeOptions{Quality: 80, EmulateJpegSize: true}
+	config, err := opts.config()
+	if err != nil {
+		t.Fatalf("config() error = %v", err)
+	}
+	if config.EmulateJpegSize != 1 {
+		t.Fatalf("EmulateJpegSize = %d, want 1", config.EmulateJpegSize)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, noiseNRGBA(48, 32), opts); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !IsWebP(buf.Bytes()) {
+		t.Fatal("Encode(EmulateJpegSize) output is not a WebP file")
+	}
+	if img, err := Decode(&buf); err != nil || img.Bounds() != image.Rect(0, 0, 48, 32) {
+		t.Fatalf("Decode(EmulateJpegSize output) = (%v, %v)", img, err)
+	}
+}
+
+func TestEncodeTargetSizeWithQMin(t *testing.T) {
+	opts := &EncodeOptions{TargetSize: 2000, QMin: 40}
+	if err := opts.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	config, err := opts.config()
+	if err != nil {
+		t.Fatalf("config() error = %v", err)
+	}
+	if config.TargetSize != 2000 || config.QMin != 40 || config.QMax != 100 {
+		t.Fatalf("config TargetSize, QMin, QMax = %d, %d, %d, want 2000, 40, 100", config.TargetSize, config.QMin, config.QMax)
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, noiseNRGBA(64, 64), opts); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if _, err := Decode(&buf); err != nil {
+		t.Fatalf("Decode(TargetSize output) error = %v", err)
+	}
+
+	for _, bad := range []*EncodeOptions{{QMin: 60, QMax: 50}, {QMax: 101}, {QMin: -1}, {TargetSize: -1}} {
+		if err := Encode(&bytes.Buffer{}, noiseNRGBA(4, 4), bad); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("Encode(%+v) error = %v, want %v", *bad, err, ErrInvalidOption)
+		}
+	}
+}
+
+func TestEncodeAlphaCompression(t *testing.T) {
+	// Soft radial alpha over a flat color.
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			d := (x-32)*(x-32) + (y-32)*(y-32)
+			src.SetNRGBA(x, y, color.NRGBA{R: 40, G: 120, B: 200, A: uint8(max(0, 255-d/4))})
+		}
+	}
+
+	for compression := range 2 {
+		for filtering := range 3 {
+			var buf bytes.Buffer
+			opts := &EncodeOptions{Quality: 90, AlphaCompression: &compression, AlphaFiltering: &filtering}
+			if err := Encode(&buf, src, opts); err != nil {
+				t.Fatalf("Encode(compression=%d, filtering=%d) error = %v", compression, filtering, err)
+			}
+			img, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode(compression=%d, filtering=%d) error = %v", compression, filtering, err)
+			}
+			got := img.(*image.NRGBA)
+			for i := 3; i < len(src.Pix); i += 4 {
+				if diff(got.Pix[i], src.Pix[i]) > 2 {
+					t.Fatalf("compression=%d, filtering=%d: alpha %d, want %d", compression, filtering, got.Pix[i], src.Pix[i])
+				}
+			}
+		}
+	}
+
+	bad := 2
+	if err := Encode(&bytes.Buffer{}, src, &EncodeOptions{AlphaCompression: &bad}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Encode(AlphaCompression=2) error = %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestEncodeUseDeltaPalette(t *testing.T) {
+	// Eight-color stripes, the kind of synthetic image palettes suit.
+	palette := []color.NRGBA{
+		{A: 255}, {R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255},
+		{R: 255, G: 255, A: 255}, {G: 255, B: 255, A: 255}, {R: 255, B: 255, A: 255}, {R: 255, G: 255, B: 255, A: 255},
+	}
+	src := image.NewNRGBA(image.Rect(0, 0, 32, 16))
+	for y := range 16 {
+		for x := range 32 {
+			src.SetNRGBA(x, y, palette[(x+y)/4%len(palette)])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, src, &EncodeOptions{Lossless: true, UseDeltaPalette: true}); err != nil {
+		t.Fatalf("Encode(UseDeltaPalette) error = %v", err)
+	}
+	img, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !bytes.Equal(img.(*image.NRGBA).Pix, src.Pix) {
+		t.Fatal("lossless UseDeltaPalette round trip changed pixels")
+	}
+
+	if err := Encode(&bytes.Buffer{}, src, &EncodeOptions{UseDeltaPalette: true}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Encode(lossy UseDeltaPalette) error = %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestEncodeWithStats(t *testing.T) {
+	var buf bytes.Buffer
+	stats, err := EncodeWithStats(&buf, noiseNRGBA(64, 64), &EncodeOptions{Quality: 70})
+	if err != nil {
+		t.Fatalf("EncodeWithStats() error = %v", err)
+	}
+	if int(stats.CodedSize) != buf.Len() {
+		t.Fatalf("CodedSize = %d, want %d", stats.CodedSize, buf.Len())
+	}
+	if stats.PSNR[4] <= 0 {
+		t.Fatalf("overall PSNR = %v, want > 0", stats.PSNR[4])
+	}
+	if stats.BlockCount[0]+stats.BlockCount[1]+stats.BlockCount[2] == 0 {
+		t.Fatal("BlockCount is empty")
+	}
+}
+
+func TestEncodeOptionsRequirementsMet(t *testing.T) {
+	near := 60
+	opts := &EncodeOptions{Lossless: true, UseSharpYuv: true, NearLossless: &near}
+	ok, unmet := opts.RequirementsMet()
+	if !ok || len(unmet) != 0 {
+		// Every libwebp this package loads (>= 0.6) supports these.
+		t.Fatalf("RequirementsMet() = (%v, %v), want (true, [])", ok, unmet)
+	}
+	if ok, unmet := (*EncodeOptions)(nil).RequirementsMet(); !ok || len(unmet) != 0 {
+		t.Fatalf("nil RequirementsMet() = (%v, %v), want (true, [])", ok, unmet)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, noiseNRGBA(16, 16), opts); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	bad := 101
+	err := Encode(&bytes.Buffer{}, noiseNRGBA(4, 4), &EncodeOptions{NearLossless: &bad})
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Encode(NearLossless=101) error = %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestEncodeYCbCrDirect(t *testing.T) {
+	// Quadrants of distinct colors; each quadrant is 8x8 so 4:2:0 chroma
+	// blocks never straddle two colors.
+	colors := [4]color.YCbCr{
+		{Y: 76, Cb: 85, Cr: 255},   // red
+		{Y: 150, Cb: 44, Cr: 21},   // green
+		{Y: 29, Cb: 255, Cr: 107},  // blue
+		{Y: 200, Cb: 128, Cr: 128}, // light gray
+	}
+	for _, ratio := range []image.YCbCrSubsampleRatio{
+		image.YCbCrSubsampleRatio420, image.YCbCrSubsampleRatio422, image.YCbCrSubsampleRatio444,
+	} {
+		t.Run(ratio.String(), func(t *testing.T) {
+			src := image.NewYCbCr(image.Rect(0, 0, 16, 16), ratio)
+			for y := range 16 {
+				for x := range 16 {
+					c := colors[y/8*2+x/8]
+					src.Y[src.YOffset(x, y)] = c.Y
+					src.Cb[src.COffset(x, y)] = c.Cb
+					src.Cr[src.COffset(x, y)] = c.Cr
+				}
+			}
+			if _, ok := encodesYCbCrDirectly(src, nil); !ok {
+				t.Fatal("YCbCr source not encoded directly")
+			}
+
+			var buf bytes.Buffer
+			if err := Encode(&buf, src, &EncodeOptions{Quality: 95}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			decoded, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got := decoded.(*image.NRGBA)
+			for i, c := range colors {
+				r, g, b := color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+				p := got.NRGBAAt(i%2*8+4, i/2*8+4)
+				if diff(p.R, r) > 6 || diff(p.G, g) > 6 || diff(p.B, b) > 6 || p.A != 255 {
+					t.Errorf("quadrant %d = %v, want about (%d, %d, %d)", i, p, r, g, b)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeYCbCrLosslessUsesRGB(t *testing.T) {
+	src := image.NewYCbCr(image.Rect(0, 0, 4, 4), image.YCbCrSubsampleRatio420)
+	if _, ok := encodesYCbCrDirectly(src, &EncodeOptions{Lossless: true}); ok {
+		t.Fatal("lossless YCbCr encode should go through RGB")
+	}
+	if _, ok := encodesYCbCrDirectly(image.NewYCbCr(src.Rect, image.YCbCrSubsampleRatio411), nil); ok {
+		t.Fatal("4:1:1 YCbCr should go through RGB")
+	}
+}
+
+func diff(a, b uint8) int {
+	return max(int(a)-int(b), int(b)-int(a))
+}
+
+func TestEncodeGrayRoundTrip(t *testing.T) {
+	// A horizontal ramp over an odd-sized, offset sub-image exercises the
+	// aliased luma plane and the chroma sizing.
+	full := image.NewGray(image.Rect(0, 0, 40, 13))
+	for y := range 13 {
+		for x := range 40 {
+			full.Pix[y*full.Stride+x] = uint8(x * 255 / 39)
+		}
+	}
+	src := full.SubImage(image.Rect(3, 1, 40, 12)).(*image.Gray)
+
+	for _, opts := range []*EncodeOptions{{Quality: 95}, {Lossless: true}} {
+		var buf bytes.Buffer
+		if err := EncodeGray(&buf, src, opts); err != nil {
+			t.Fatalf("EncodeGray(lossless=%v) error = %v", opts.Lossless, err)
+		}
+		img, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		got := img.(*image.NRGBA)
+		if got.Rect.Size() != src.Rect.Size() {
+			t.Fatalf("decoded size = %v, want %v", got.Rect.Size(), src.Rect.Size())
+		}
+		tolerance := 4
+		if opts.Lossless {
+			tolerance = 0
+		}
+		for y := range src.Rect.Dy() {
+			for x := range src.Rect.Dx() {
+				want := src.GrayAt(src.Rect.Min.X+x, src.Rect.Min.Y+y).Y
+				c := got.NRGBAAt(x, y)
+				if diff(c.R, want) > tolerance || diff(c.G, want) > tolerance || diff(c.B, want) > tolerance || c.A != 0xff {
+					t.Fatalf("lossless=%v pixel (%d, %d) = %v, want gray %d", opts.Lossless, x, y, c, want)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodePalettedRoundTrip(t *testing.T) {
+	palette := color.Palette{
+		color.NRGBA{0, 0, 0, 0xff},
+		color.NRGBA{0xff, 0x20, 0x10, 0xff},
+		color.NRGBA{0x10, 0xc0, 0x40, 0xff},
+		color.NRGBA{0x30, 0x50, 0xf0, 0x80},
+		color.NRGBA{0xff, 0xff, 0xff, 0xff},
+	}
+	src := image.NewPaletted(image.Rect(0, 0, 23, 17), palette)
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 7 % len(palette))
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePaletted(&buf, src, nil); err != nil {
+		t.Fatalf("EncodePaletted() error = %v", err)
+	}
+	img, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got := img.(*image.NRGBA)
+	for y := range 17 {
+		for x := range 23 {
+			want := palette[src.ColorIndexAt(x, y)].(color.NRGBA)
+			if c := got.NRGBAAt(x, y); c != want {
+				t.Fatalf("pixel (%d, %d) = %v, want palette entry %v", x, y, c, want)
+			}
+		}
+	}
+}
+
+func TestEstimateSizeMatchesEncode(t *testing.T) {
+	src := noiseNRGBA(48, 32)
+	for _, opts := range []*EncodeOptions{nil, {Quality: 40}, {Lossless: true}, {Quality: 80, Method: new(6)}} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, src, opts); err != nil {
+			t.Fatalf("Encode(%+v) error = %v", opts, err)
+		}
+		got, err := EstimateSize(src, opts)
+		if err != nil {
+			t.Fatalf("EstimateSize(%+v) error = %v", opts, err)
+		}
+		if got != buf.Len() {
+			t.Fatalf("EstimateSize(%+v) = %d, want %d", opts, got, buf.Len())
+		}
+	}
+
+	if _, err := EstimateSize(src, &EncodeOptions{Quality: 101}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("EstimateSize(Quality 101) error = %v, want %v", err, ErrInvalidOption)
+	}
+}
@@ -0,0 +1,41 @@
+package webp
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"os"
+)
+
+// EncodeFile encodes img as WebP with opts and writes it to path, creating or
+// truncating the file. Errors are those of Encode plus any file I/O error; on
+// failure the partially written file is removed.
+func EncodeFile(path string, img image.Image, opts *EncodeOptions) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	if err := Encode(bw, img, opts); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodeFile reads the WebP file at path and decodes it like Decode.
+func DecodeFile(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(bytes.NewReader(data))
+}
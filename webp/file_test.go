@@ -0,0 +1,68 @@
+package webp
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeFileDecodeFileRoundTrip(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 32, 24))
+	for y := range 24 {
+		for x := range 32 {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 8), G: uint8(y * 10), B: 128, A: 255})
+		}
+	}
+	dir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		opts      *EncodeOptions
+		tolerance int
+	}{
+		{name: "lossless", opts: &EncodeOptions{Lossless: true}, tolerance: 0},
+		{name: "lossy", opts: &EncodeOptions{Quality: 100}, tolerance: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".webp")
+			if err := EncodeFile(path, src, tt.opts); err != nil {
+				t.Fatalf("EncodeFile() error = %v", err)
+			}
+			img, err := DecodeFile(path)
+			if err != nil {
+				t.Fatalf("DecodeFile() error = %v", err)
+			}
+			got := img.(*image.NRGBA)
+			if got.Rect != src.Rect {
+				t.Fatalf("bounds = %v, want %v", got.Rect, src.Rect)
+			}
+			for i := range src.Pix {
+				if d := int(got.Pix[i]) - int(src.Pix[i]); d > tt.tolerance || d < -tt.tolerance {
+					t.Fatalf("Pix[%d] = %d, want %d ± %d", i, got.Pix[i], src.Pix[i], tt.tolerance)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeFileRemovesFileOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.webp")
+	err := EncodeFile(path, image.NewNRGBA(image.Rect(0, 0, 0, 0)), nil)
+	if err == nil {
+		t.Fatal("EncodeFile(empty image) error = nil")
+	}
+	if _, statErr := os.Stat(path); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("partial file left behind: stat error = %v", statErr)
+	}
+}
+
+func TestDecodeFileMissing(t *testing.T) {
+	_, err := DecodeFile(filepath.Join(t.TempDir(), "missing.webp"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("DecodeFile(missing) error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,167 @@
+package webp
+
+import (
+	"errors"
+	"image"
+	"runtime"
+	"unsafe"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// ErrNotEnoughData is returned by IncrementalDecoder.Image before enough
+// data has been written to decode the image header.
+var ErrNotEnoughData = errors.New("webp: not enough data to decode the image yet")
+
+// IncrementalDecoder decodes a WebP image as its bytes arrive, for example
+// while it is being downloaded. Write the data in chunks of any size, then
+// call Image to get what has been decoded so far. Close must be called to
+// release the libwebp decoder.
+//
+// An IncrementalDecoder is not safe for concurrent use.
+type IncrementalDecoder struct {
+	idec     uintptr
+	progress func(decodedRows, totalRows int)
+	flipH    bool
+	flipV    bool
+
+	// config is read by libwebp for the lifetime of idec, so it stays
+	// pinned until Close.
+	config *libwebp.DecoderConfig
+	pinner runtime.Pinner
+}
+
+// NewIncrementalDecoder returns an incremental decoder producing
+// non-premultiplied RGBA output in libwebp-owned memory, decoded as
+// configured by opts; nil opts decodes the whole image, like Decode.
+//
+// Crop and Scale are applied as the data arrives, so a thumbnail never
+// holds the full-size image. As the image size is not known yet, a crop
+// outside the image is only reported by the Write that completes the
+// header, as a *libwebp.StatusError.
+func NewIncrementalDecoder(opts *DecodeOptions) (*IncrementalDecoder, error) {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	config := new(libwebp.DecoderConfig)
+	ok, err := libwebp.WebPInitDecoderConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrDecodeFailed
+	}
+	config.Output.Colorspace = int32(libwebp.ModeRGBA)
+	opts.decoderOptions(&config.Options)
+	// libwebp fills a flipped output bottom up with a negative stride until
+	// the decode completes; Image flips the rows while copying instead.
+	config.Options.Flip = 0
+
+	d := &IncrementalDecoder{config: config, flipH: opts.FlipHorizontally, flipV: opts.FlipVertically}
+	d.pinner.Pin(config)
+	d.idec, err = libwebp.WebPIDecode(nil, config)
+	if err != nil {
+		d.pinner.Unpin()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Write appends p to the decoder's input and decodes as far as possible. It
+// returns len(p) unless the data is invalid, in which case the error is a
+// *libwebp.StatusError.
+func (d *IncrementalDecoder) Write(p []byte) (int, error) {
+	if d.idec == 0 {
+		return 0, libwebp.ErrInvalidData
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	status, err := libwebp.WebPIAppend(d.idec, p)
+	if err != nil {
+		return 0, err
+	}
+	if status != libwebp.VP8StatusOK && status != libwebp.VP8StatusSuspended {
+		return 0, libwebp.ErrorFromStatus(status)
+	}
+	d.reportProgress()
+	return len(p), nil
+}
+
+// OnProgress sets fn to be called after every successful Write once the
+// header is decoded, with the number of fully decoded rows and the image
+// height, so that a partial image can be shown while data arrives. A nil fn
+// removes the callback. It is never called after Close.
+func (d *IncrementalDecoder) OnProgress(fn func(decodedRows, totalRows int)) {
+	d.progress = fn
+}
+
+// reportProgress calls the OnProgress callback, if any.
+func (d *IncrementalDecoder) reportProgress() {
+	if d.progress == nil {
+		return
+	}
+	var lastY, width, height, stride int32
+	if _, err := libwebp.WebPIDecGetRGB(d.idec, &lastY, &width, &height, &stride); err != nil {
+		return
+	}
+	d.progress(int(lastY), int(height))
+}
+
+// Image returns a copy of the image decoded so far and the number of rows
+// that are fully decoded; rows from lastRow on are transparent black.
+// The image is complete when lastRow equals its height. Before the header
+// has been decoded Image returns ErrNotEnoughData.
+//
+// With DecodeOptions.FlipVertically the decoded rows are the bottom lastRow
+// rows instead, and the transparent ones are at the top.
+func (d *IncrementalDecoder) Image() (img image.Image, lastRow int, err error) {
+	if d.idec == 0 {
+		return nil, 0, libwebp.ErrInvalidData
+	}
+	var lastY, width, height, stride int32
+	ptr, err := libwebp.WebPIDecGetRGB(d.idec, &lastY, &width, &height, &stride)
+	if err != nil {
+		if errors.Is(err, libwebp.ErrDecodeFailed) {
+			return nil, 0, ErrNotEnoughData
+		}
+		return nil, 0, err
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	if lastY > 0 {
+		rows := int(lastY)
+		src := unsafe.Slice(*(**byte)(unsafe.Pointer(&ptr)), int(stride)*(rows-1)+out.Stride)
+		for y := range rows {
+			dy := y
+			if d.flipV {
+				dy = int(height) - 1 - y
+			}
+			copy(out.Pix[dy*out.Stride:(dy+1)*out.Stride], src[y*int(stride):])
+		}
+	}
+	if d.flipH {
+		mirrorNRGBA(out)
+	}
+	return out, int(lastY), nil
+}
+
+// Close deletes the libwebp decoder and frees its output. Further calls to
+// Write and Image fail and Close is a no-op.
+func (d *IncrementalDecoder) Close() error {
+	if d.idec == 0 {
+		return nil
+	}
+	err := libwebp.WebPIDelete(d.idec)
+	if ferr := libwebp.WebPFreeDecBuffer(&d.config.Output); err == nil {
+		err = ferr
+	}
+	d.pinner.Unpin()
+	d.idec = 0
+	d.config = nil
+	d.progress = nil
+	return err
+}
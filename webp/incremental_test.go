@@ -0,0 +1,230 @@
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func TestIncrementalDecoderChunks(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 33, 20))
+	for y := range 20 {
+		for x := range 33 {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 7), G: uint8(y * 11), B: uint8(x ^ y), A: uint8(100 + x + y)})
+		}
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	dec, err := NewIncrementalDecoder(nil)
+	if err != nil {
+		t.Fatalf("NewIncrementalDecoder(nil) error = %v", err)
+	}
+	defer dec.Close()
+
+	if _, _, err := dec.Image(); !errors.Is(err, ErrNotEnoughData) {
+		t.Fatalf("Image() before data error = %v, want %v", err, ErrNotEnoughData)
+	}
+
+	const chunk = 17
+	for off := 0; off < len(data); off += chunk {
+		n, err := dec.Write(data[off:min(off+chunk, len(data))])
+		if err != nil {
+			t.Fatalf("Write(at %d) error = %v", off, err)
+		}
+		if n != min(chunk, len(data)-off) {
+			t.Fatalf("Write(at %d) = %d", off, n)
+		}
+	}
+
+	img, lastRow, err := dec.Image()
+	if err != nil {
+		t.Fatalf("Image() error = %v", err)
+	}
+	if lastRow != 20 {
+		t.Fatalf("Image() lastRow = %d, want 20", lastRow)
+	}
+	got := img.(*image.NRGBA)
+	if got.Rect != src.Rect || !bytes.Equal(got.Pix, src.Pix) {
+		t.Fatal("Image() does not match the source image")
+	}
+
+	if err := dec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := dec.Write(data[:1]); !errors.Is(err, libwebp.ErrInvalidData) {
+		t.Fatalf("Write() after Close error = %v, want %v", err, libwebp.ErrInvalidData)
+	}
+}
+
+func TestIncrementalDecoderInvalidData(t *testing.T) {
+	dec, err := NewIncrementalDecoder(nil)
+	if err != nil {
+		t.Fatalf("NewIncrementalDecoder(nil) error = %v", err)
+	}
+	defer dec.Close()
+
+	var statusErr *libwebp.StatusError
+	if _, err := dec.Write(bytes.Repeat([]byte("garbage!"), 8)); !errors.As(err, &statusErr) {
+		t.Fatalf("Write(garbage) error = %v, want a *libwebp.StatusError", err)
+	}
+}
+
+func TestIncrementalDecoderOnProgress(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 96, 80))
+	for y := range 80 {
+		for x := range 96 {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 3), G: uint8(y * 3), B: uint8(x * y), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, src, &EncodeOptions{Quality: 80}); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	data := buf.Bytes()
+
+	dec, err := NewIncrementalDecoder(nil)
+	if err != nil {
+		t.Fatalf("NewIncrementalDecoder(nil) error = %v", err)
+	}
+	var rows []int
+	dec.OnProgress(func(decodedRows, totalRows int) {
+		if totalRows != 80 {
+			t.Errorf("totalRows = %d, want 80", totalRows)
+		}
+		rows = append(rows, decodedRows)
+	})
+
+	for off := 0; off < len(data); off += 64 {
+		if _, err := dec.Write(data[off:min(off+64, len(data))]); err != nil {
+			t.Fatalf("Write(at %d) error = %v", off, err)
+		}
+	}
+	if len(rows) == 0 || rows[len(rows)-1] != 80 {
+		t.Fatalf("progress rows = %v, want to end at 80", rows)
+	}
+	for i := 1; i < len(rows); i++ {
+		if rows[i] < rows[i-1] {
+			t.Fatalf("progress rows decreased: %v", rows)
+		}
+	}
+
+	calls := len(rows)
+	if err := dec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	dec.Write(data[:1])
+	if len(rows) != calls {
+		t.Fatal("progress callback ran after Close")
+	}
+}
+
+func TestIncrementalDecoderWithOptions(t *testing.T) {
+	src := patternNRGBA(96, 80)
+	var buf bytes.Buffer
+	if err := Encode(&buf, src, &EncodeOptions{Quality: 90}); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	data := buf.Bytes()
+
+	for _, tt := range []struct {
+		opts *DecodeOptions
+		want image.Rectangle
+	}{
+		{&DecodeOptions{Scale: image.Pt(48, 40)}, image.Rect(0, 0, 48, 40)},
+		{&DecodeOptions{Scale: image.Pt(24, 0)}, image.Rect(0, 0, 24, 20)},
+		{&DecodeOptions{Crop: image.Rect(16, 8, 80, 72), Scale: image.Pt(32, 32)}, image.Rect(0, 0, 32, 32)},
+	} {
+		dec, err := NewIncrementalDecoder(tt.opts)
+		if err != nil {
+			t.Fatalf("NewIncrementalDecoder(%+v) error = %v", tt.opts, err)
+		}
+		for off := 0; off < len(data); off += 100 {
+			if _, err := dec.Write(data[off:min(off+100, len(data))]); err != nil {
+				t.Fatalf("Write(at %d) error = %v", off, err)
+			}
+		}
+		img, lastRow, err := dec.Image()
+		if err != nil {
+			t.Fatalf("Image() error = %v", err)
+		}
+		if img.Bounds() != tt.want || lastRow != tt.want.Dy() {
+			t.Fatalf("Image() with %+v = %v, %d rows, want %v complete", tt.opts, img.Bounds(), lastRow, tt.want)
+		}
+
+		// The whole-image decode with the same options is the reference.
+		want, err := decodeWithOptions(data, tt.opts)
+		if err != nil {
+			t.Fatalf("decodeWithOptions(%+v) error = %v", tt.opts, err)
+		}
+		if !bytes.Equal(img.(*image.NRGBA).Pix, want.Pix) {
+			t.Errorf("incremental decode with %+v differs from DecodeWithOptions", tt.opts)
+		}
+		if err := dec.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	if _, err := NewIncrementalDecoder(&DecodeOptions{Scale: image.Pt(-1, 10)}); !errors.Is(err, ErrInvalidScale) {
+		t.Fatalf("NewIncrementalDecoder(negative scale) error = %v, want %v", err, ErrInvalidScale)
+	}
+}
+
+func TestIncrementalDecoderFlipVerticallyPartial(t *testing.T) {
+	src := patternNRGBA(64, 64)
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	opts := &DecodeOptions{FlipVertically: true}
+	dec, err := NewIncrementalDecoder(opts)
+	if err != nil {
+		t.Fatalf("NewIncrementalDecoder() error = %v", err)
+	}
+	defer dec.Close()
+
+	part := len(data) * 3 / 4
+	if _, err := dec.Write(data[:part]); err != nil {
+		t.Fatalf("Write(partial) error = %v", err)
+	}
+	img, lastRow, err := dec.Image()
+	if err != nil {
+		t.Fatalf("Image() after partial write error = %v", err)
+	}
+	if lastRow <= 0 || lastRow >= 64 {
+		t.Fatalf("Image() lastRow = %d after %d of %d bytes, want a partial decode", lastRow, part, len(data))
+	}
+	got := img.(*image.NRGBA)
+	for y := range 64 {
+		for x := range 64 {
+			want := color.NRGBA{}
+			if y >= 64-lastRow {
+				want = src.NRGBAAt(x, 63-y)
+			}
+			if c := got.NRGBAAt(x, y); c != want {
+				t.Fatalf("partial pixel (%d, %d) = %v, want %v (lastRow %d)", x, y, c, want, lastRow)
+			}
+		}
+	}
+
+	if _, err := dec.Write(data[part:]); err != nil {
+		t.Fatalf("Write(rest) error = %v", err)
+	}
+	img, lastRow, err = dec.Image()
+	if err != nil || lastRow != 64 {
+		t.Fatalf("Image() after full write = (%d rows, %v), want 64 rows", lastRow, err)
+	}
+	want, err := decodeWithOptions(data, opts)
+	if err != nil {
+		t.Fatalf("decodeWithOptions() error = %v", err)
+	}
+	if !bytes.Equal(img.(*image.NRGBA).Pix, want.Pix) {
+		t.Fatal("flipped incremental decode differs from DecodeWithOptions")
+	}
+}
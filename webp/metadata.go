@@ -0,0 +1,149 @@
+package webp
+
+import (
+	"fmt"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// ReadICCProfile returns the embedded ICC color profile of data, or nil if it
+// has none. It requires libwebpdemux.
+func ReadICCProfile(data []byte) ([]byte, error) {
+	payload, _, err := readChunk(data, "ICCP")
+	return payload, err
+}
+
+// SetICCProfile returns a copy of data carrying icc as its ICC color
+// profile, replacing any existing one. An empty icc removes the profile. The
+// image data is not re-encoded. It requires libwebpmux.
+func SetICCProfile(data []byte, icc []byte) ([]byte, error) {
+	return setChunk(data, "ICCP", icc)
+}
+
+// ReadEXIF returns the raw EXIF metadata of data, or nil if it has none. It
+// requires libwebpdemux.
+func ReadEXIF(data []byte) ([]byte, error) {
+	payload, _, err := readChunk(data, "EXIF")
+	return payload, err
+}
+
+// SetEXIF returns a copy of data carrying exif as its EXIF metadata,
+// replacing any existing block. A nil or empty exif strips it. The image
+// data is not re-encoded. It requires libwebpmux.
+func SetEXIF(data []byte, exif []byte) ([]byte, error) {
+	return setChunk(data, "EXIF", exif)
+}
+
+// ReadXMP returns the raw XMP metadata of data, or nil if it has none. It
+// requires libwebpdemux.
+func ReadXMP(data []byte) ([]byte, error) {
+	payload, _, err := readChunk(data, "XMP ")
+	return payload, err
+}
+
+// SetXMP returns a copy of data carrying xmp as its XMP metadata, replacing
+// any existing packet. A nil or empty xmp strips it. The image data is not
+// re-encoded. It requires libwebpmux.
+func SetXMP(data []byte, xmp []byte) ([]byte, error) {
+	return setChunk(data, "XMP ", xmp)
+}
+
+// ReadXMPString is like ReadXMP but returns the UTF-8 XMP packet as a string,
+// empty if there is none.
+func ReadXMPString(data []byte) (string, error) {
+	xmp, err := ReadXMP(data)
+	return string(xmp), err
+}
+
+// SetXMPString is like SetXMP with the XMP packet given as a string.
+func SetXMPString(data []byte, xmp string) ([]byte, error) {
+	return SetXMP(data, []byte(xmp))
+}
+
+// StripMetadata returns a copy of data without its ICC profile, EXIF and XMP
+// chunks. Image data and animation frames are kept as is, without
+// re-encoding. It requires libwebpmux.
+func StripMetadata(data []byte) ([]byte, error) {
+	mux, err := libwebp.WebPMuxCreate(data)
+	if err != nil {
+		return nil, err
+	}
+	defer libwebp.WebPMuxDelete(mux)
+
+	for _, fourcc := range []string{"ICCP", "EXIF", "XMP "} {
+		status, err := libwebp.WebPMuxDeleteChunk(mux, fourcc)
+		if err != nil {
+			return nil, err
+		}
+		if status != libwebp.MuxOK && status != libwebp.MuxNotFound {
+			return nil, muxStatusError("delete "+fourcc, status)
+		}
+	}
+	return assembleMux(mux)
+}
+
+// readChunk returns a copy of the first chunk with the given FourCC and
+// whether the container has one.
+func readChunk(data []byte, fourcc string) ([]byte, bool, error) {
+	dmux, err := libwebp.WebPDemux(data)
+	if err != nil {
+		return nil, false, err
+	}
+	defer libwebp.WebPDemuxDelete(dmux)
+
+	var iter libwebp.ChunkIterator
+	ok, err := libwebp.WebPDemuxGetChunk(dmux, fourcc, 1, &iter)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	defer libwebp.WebPDemuxReleaseChunkIterator(&iter)
+	return libwebp.ChunkIteratorBytes(&iter), true, nil
+}
+
+// setChunk rebuilds data with payload as its fourcc chunk; an empty payload
+// deletes the chunk instead.
+func setChunk(data []byte, fourcc string, payload []byte) ([]byte, error) {
+	mux, err := libwebp.WebPMuxCreate(data)
+	if err != nil {
+		return nil, err
+	}
+	defer libwebp.WebPMuxDelete(mux)
+
+	var status libwebp.MuxError
+	if len(payload) == 0 {
+		status, err = libwebp.WebPMuxDeleteChunk(mux, fourcc)
+		if status == libwebp.MuxNotFound {
+			status = libwebp.MuxOK
+		}
+	} else {
+		status, err = libwebp.WebPMuxSetChunk(mux, fourcc, payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != libwebp.MuxOK {
+		return nil, muxStatusError("set "+fourcc, status)
+	}
+
+	return assembleMux(mux)
+}
+
+func assembleMux(mux uintptr) ([]byte, error) {
+	out, status, err := libwebp.WebPMuxAssemble(mux)
+	if err != nil {
+		return nil, err
+	}
+	if status != libwebp.MuxOK {
+		return nil, muxStatusError("assemble", status)
+	}
+	return out, nil
+}
+
+// muxStatusError converts a failed libwebpmux status into an error; bad or
+// truncated input matches libwebp.ErrInvalidData.
+func muxStatusError(op string, status libwebp.MuxError) error {
+	if status == libwebp.MuxBadData || status == libwebp.MuxNotEnoughData {
+		return fmt.Errorf("%w: %s: %s", libwebp.ErrInvalidData, op, status)
+	}
+	return fmt.Errorf("webp: %s: %s", op, status)
+}
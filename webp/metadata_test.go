@@ -0,0 +1,152 @@
+package webp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func requireMetadata(t testing.TB) {
+	t.Helper()
+	requireMux(t)
+	requireDemux(t)
+}
+
+func TestICCProfileRoundTrip(t *testing.T) {
+	requireMetadata(t)
+	data, want := testWebP(t)
+
+	if icc, err := ReadICCProfile(data); err != nil || icc != nil {
+		t.Fatalf("ReadICCProfile(no profile) = (%v, %v), want (nil, nil)", icc, err)
+	}
+
+	icc := []byte("dummy ICC profile blob")
+	withICC, err := SetICCProfile(data, icc)
+	if err != nil {
+		t.Fatalf("SetICCProfile() error = %v", err)
+	}
+	got, err := ReadICCProfile(withICC)
+	if err != nil {
+		t.Fatalf("ReadICCProfile() error = %v", err)
+	}
+	if !bytes.Equal(got, icc) {
+		t.Fatalf("ReadICCProfile() = %q, want %q", got, icc)
+	}
+	img, err := decodeNRGBA(withICC)
+	if err != nil {
+		t.Fatalf("decode image with ICC: %v", err)
+	}
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Fatal("SetICCProfile changed the image pixels")
+	}
+
+	stripped, err := SetICCProfile(withICC, nil)
+	if err != nil {
+		t.Fatalf("SetICCProfile(nil) error = %v", err)
+	}
+	if got, err := ReadICCProfile(stripped); err != nil || got != nil {
+		t.Fatalf("ReadICCProfile(removed) = (%v, %v), want (nil, nil)", got, err)
+	}
+
+	garbage := []byte("not a webp file at all")
+	if _, err := ReadICCProfile(garbage); err == nil {
+		t.Fatal("ReadICCProfile(garbage) succeeded")
+	}
+	if _, err := SetICCProfile(garbage, icc); err == nil {
+		t.Fatal("SetICCProfile(garbage) succeeded")
+	}
+}
+
+func TestEXIFRoundTrip(t *testing.T) {
+	requireMetadata(t)
+	data, want := testWebP(t)
+
+	exif := append([]byte("Exif\x00\x00II*\x00"), bytes.Repeat([]byte{0xab}, 33)...)
+	withEXIF, err := SetEXIF(data, exif)
+	if err != nil {
+		t.Fatalf("SetEXIF() error = %v", err)
+	}
+	got, err := ReadEXIF(withEXIF)
+	if err != nil {
+		t.Fatalf("ReadEXIF() error = %v", err)
+	}
+	if !bytes.Equal(got, exif) {
+		t.Fatalf("ReadEXIF() = %x, want %x", got, exif)
+	}
+	img, err := decodeNRGBA(withEXIF)
+	if err != nil {
+		t.Fatalf("decode image with EXIF: %v", err)
+	}
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Fatal("SetEXIF changed the image pixels")
+	}
+
+	stripped, err := SetEXIF(withEXIF, nil)
+	if err != nil {
+		t.Fatalf("SetEXIF(nil) error = %v", err)
+	}
+	if got, err := ReadEXIF(stripped); err != nil || got != nil {
+		t.Fatalf("ReadEXIF(stripped) = (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func TestXMPRoundTrip(t *testing.T) {
+	requireMetadata(t)
+	data, _ := testWebP(t)
+
+	// Odd length to exercise RIFF chunk padding.
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF/></x:xmpmeta>!`
+	withXMP, err := SetXMPString(data, xmp)
+	if err != nil {
+		t.Fatalf("SetXMPString() error = %v", err)
+	}
+	got, err := ReadXMP(withXMP)
+	if err != nil {
+		t.Fatalf("ReadXMP() error = %v", err)
+	}
+	if !bytes.Equal(got, []byte(xmp)) {
+		t.Fatalf("ReadXMP() = %q, want %q", got, xmp)
+	}
+	if s, err := ReadXMPString(withXMP); err != nil || s != xmp {
+		t.Fatalf("ReadXMPString() = (%q, %v), want %q", s, err, xmp)
+	}
+	if _, err := decodeNRGBA(withXMP); err != nil {
+		t.Fatalf("decode image with XMP: %v", err)
+	}
+	if s, err := ReadXMPString(data); err != nil || s != "" {
+		t.Fatalf("ReadXMPString(no XMP) = (%q, %v), want empty", s, err)
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	requireMetadata(t)
+	data := metadataWebP(t, []byte("icc profile"), bytes.Repeat([]byte("exif"), 64))
+
+	stripped, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if len(stripped) >= len(data) {
+		t.Fatalf("StripMetadata() size = %d, want < %d", len(stripped), len(data))
+	}
+	chunks, err := ListChunks(stripped)
+	if err != nil {
+		t.Fatalf("ListChunks() error = %v", err)
+	}
+	for _, fourcc := range chunks {
+		if fourcc == "ICCP" || fourcc == "EXIF" || fourcc == "XMP " {
+			t.Fatalf("StripMetadata() output still has %q: %q", fourcc, chunks)
+		}
+	}
+	if _, err := decodeNRGBA(stripped); err != nil {
+		t.Fatalf("decode stripped image: %v", err)
+	}
+
+	anim, _ := testAnimation(t)
+	strippedAnim, err := StripMetadata(anim)
+	if err != nil {
+		t.Fatalf("StripMetadata(animation) error = %v", err)
+	}
+	if info, err := Inspect(strippedAnim); err != nil || !info.HasAnimation || info.FrameCount != 3 {
+		t.Fatalf("Inspect(stripped animation) = (%+v, %v), want 3 animated frames", info, err)
+	}
+}
@@ -0,0 +1,75 @@
+package webp
+
+import "github.com/bnema/purego-webp/libwebp"
+
+// Muxer assembles a WebP container from an encoded image and auxiliary
+// chunks such as ICCP, EXIF and "XMP " metadata, without re-encoding. It
+// requires libwebpmux. Close must be called to release the libwebp mux
+// object.
+//
+// A Muxer is not safe for concurrent use.
+type Muxer struct {
+	mux uintptr
+}
+
+// NewMuxer returns an empty Muxer.
+func NewMuxer() (*Muxer, error) {
+	mux, err := libwebp.WebPMuxNew()
+	if err != nil {
+		return nil, err
+	}
+	return &Muxer{mux: mux}, nil
+}
+
+// SetImage sets the image of the container from data, a WebP file as
+// produced by Encode or a bare VP8/VP8L bitstream; data is copied. Only the
+// image of a WebP file is taken, not its metadata chunks.
+func (m *Muxer) SetImage(data []byte) error {
+	if m.mux == 0 {
+		return libwebp.ErrInvalidData
+	}
+	status, err := libwebp.WebPMuxSetImage(m.mux, data)
+	if err != nil {
+		return err
+	}
+	if status != libwebp.MuxOK {
+		return muxStatusError("set image", status)
+	}
+	return nil
+}
+
+// SetChunk adds, or replaces, the chunk with the given FourCC, copying data.
+// Image and animation FourCCs are rejected; use SetImage.
+func (m *Muxer) SetChunk(fourcc string, data []byte) error {
+	if m.mux == 0 {
+		return libwebp.ErrInvalidData
+	}
+	status, err := libwebp.WebPMuxSetChunk(m.mux, fourcc, data)
+	if err != nil {
+		return err
+	}
+	if status != libwebp.MuxOK {
+		return muxStatusError("set "+fourcc, status)
+	}
+	return nil
+}
+
+// Assemble returns the WebP file holding the image and chunks set so far.
+// The Muxer stays usable.
+func (m *Muxer) Assemble() ([]byte, error) {
+	if m.mux == 0 {
+		return nil, libwebp.ErrInvalidData
+	}
+	return assembleMux(m.mux)
+}
+
+// Close deletes the libwebp mux object. Further calls fail and Close is a
+// no-op.
+func (m *Muxer) Close() error {
+	if m.mux == 0 {
+		return nil
+	}
+	err := libwebp.WebPMuxDelete(m.mux)
+	m.mux = 0
+	return err
+}
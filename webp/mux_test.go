@@ -0,0 +1,59 @@
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+func TestMuxerImageWithEXIF(t *testing.T) {
+	requireMetadata(t)
+	var buf bytes.Buffer
+	if err := Encode(&buf, noiseNRGBA(32, 24), &EncodeOptions{Quality: 80}); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	want, err := decodeNRGBA(buf.Bytes())
+	if err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	m, err := NewMuxer()
+	if err != nil {
+		t.Fatalf("NewMuxer() error = %v", err)
+	}
+	defer m.Close()
+	if err := m.SetImage(buf.Bytes()); err != nil {
+		t.Fatalf("SetImage() error = %v", err)
+	}
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x00")
+	if err := m.SetChunk("EXIF", exif); err != nil {
+		t.Fatalf("SetChunk(EXIF) error = %v", err)
+	}
+	if err := m.SetChunk("VP8 ", buf.Bytes()); err == nil {
+		t.Fatal("SetChunk(VP8 ) succeeded, want image FourCCs rejected")
+	}
+	data, err := m.Assemble()
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+
+	if got, err := ReadEXIF(data); err != nil || !bytes.Equal(got, exif) {
+		t.Fatalf("ReadEXIF(assembled) = (%q, %v), want %q", got, err, exif)
+	}
+	got, err := decodeNRGBA(data)
+	if err != nil {
+		t.Fatalf("decode assembled: %v", err)
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Fatal("muxing changed the image pixels")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := m.SetImage(buf.Bytes()); !errors.Is(err, libwebp.ErrInvalidData) {
+		t.Fatalf("SetImage() after Close error = %v, want %v", err, libwebp.ErrInvalidData)
+	}
+}
@@ -0,0 +1,202 @@
+package webp
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+var (
+	// ErrInvalidCrop indicates a DecodeOptions.Crop rectangle that does not
+	// lie within the image bounds.
+	ErrInvalidCrop = errors.New("webp: crop rectangle outside image bounds")
+	// ErrInvalidScale indicates a DecodeOptions.Scale with a negative
+	// dimension.
+	ErrInvalidScale = errors.New("webp: invalid scale dimensions")
+	// ErrInvalidDecodeOption indicates another DecodeOptions field is out
+	// of range.
+	ErrInvalidDecodeOption = errors.New("webp: invalid decode option")
+)
+
+// DecodeOptions configures DecodeWithOptions. The zero value decodes the
+// whole image, like Decode.
+type DecodeOptions struct {
+	// Crop selects the region to decode, in source image coordinates,
+	// regardless of Scale. The empty rectangle decodes the whole image.
+	// libwebp decodes only the rows and columns it needs, so this is cheaper
+	// than cropping afterwards.
+	Crop image.Rectangle
+	// Scale is the size to resample the cropped region to while decoding,
+	// which is much faster than resizing afterwards. The zero value keeps
+	// the cropped size; when only one dimension is zero it is derived from
+	// the other to keep the aspect ratio of the cropped region.
+	Scale image.Point
+	// FlipVertically makes libwebp write the rows bottom to top, as
+	// expected by OpenGL texture uploads.
+	FlipVertically bool
+	// FlipHorizontally mirrors the columns of the decoded image. libwebp
+	// cannot do this, so it is done in Go after decoding; combined with
+	// FlipVertically it rotates the image by 180°.
+	FlipHorizontally bool
+	// DitheringStrength dithers the color of lossy images, in [0, 100], to
+	// reduce banding on low bit-depth displays. 0 disables it.
+	DitheringStrength int
+	// AlphaDitheringStrength smooths the quantized alpha of lossy images, in
+	// [0, 100]. 0 disables it.
+	AlphaDitheringStrength int
+	// NoFancyUpsampling replaces the smooth chroma upsampling of lossy
+	// images with pixel replication: faster, slightly blockier colors.
+	NoFancyUpsampling bool
+	// BypassFiltering skips the in-loop deblocking filter of lossy images:
+	// faster, with visible block edges at low qualities.
+	BypassFiltering bool
+	// UseThreads lets libwebp decode a lossy image's filtering on a second
+	// thread. It only pays off for large images (roughly a megapixel and
+	// up); small ones decode faster without the thread handoff.
+	UseThreads bool
+}
+
+// DefaultDecodeOptions returns the options matching a plain Decode: the
+// whole image at its original size, top row first, no dithering, fancy
+// upsampling and in-loop filtering on, single-threaded. They are the zero
+// DecodeOptions, spelled out as a starting point to tweak.
+func DefaultDecodeOptions() *DecodeOptions {
+	return &DecodeOptions{}
+}
+
+// validate checks the ranges of the fields of o that libwebp would
+// otherwise clamp or ignore.
+func (o *DecodeOptions) validate() error {
+	if o.Scale.X < 0 || o.Scale.Y < 0 {
+		return ErrInvalidScale
+	}
+	if o.DitheringStrength < 0 || o.DitheringStrength > 100 {
+		return fmt.Errorf("%w: DitheringStrength %d out of range [0, 100]", ErrInvalidDecodeOption, o.DitheringStrength)
+	}
+	if o.AlphaDitheringStrength < 0 || o.AlphaDitheringStrength > 100 {
+		return fmt.Errorf("%w: AlphaDitheringStrength %d out of range [0, 100]", ErrInvalidDecodeOption, o.AlphaDitheringStrength)
+	}
+	return nil
+}
+
+// DecodeWithOptions reads a WebP image from r and decodes it as configured
+// by opts; nil opts behaves like Decode. The returned *image.NRGBA has its
+// origin at (0, 0) whatever the crop.
+//
+// Crop and Scale combine in a single libwebp decode pass: the crop is taken
+// first and the cropped region is then scaled, so a thumbnail of a region
+// never decodes the pixels outside it.
+func DecodeWithOptions(r io.Reader, opts *DecodeOptions) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeWithOptions(data, opts)
+}
+
+func decodeWithOptions(data []byte, opts *DecodeOptions) (*image.NRGBA, error) {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	w, h, ok, err := libwebp.WebPGetInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrInvalidData
+	}
+
+	var config libwebp.DecoderConfig
+	ok, err = libwebp.WebPInitDecoderConfig(&config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrDecodeFailed
+	}
+	config.Output.Colorspace = int32(libwebp.ModeRGBA)
+
+	outW, outH := w, h
+	if crop := opts.Crop; !crop.Empty() {
+		if !crop.In(image.Rect(0, 0, w, h)) {
+			return nil, ErrInvalidCrop
+		}
+		outW, outH = crop.Dx(), crop.Dy()
+	}
+	if scale := opts.Scale; scale != (image.Point{}) {
+		outW, outH = scaledSize(outW, outH, scale)
+	}
+	opts.decoderOptions(&config.Options)
+	if config.Options.UseScaling != 0 {
+		config.Options.ScaledWidth = int32(outW)
+		config.Options.ScaledHeight = int32(outH)
+	}
+	_, size, err := decodeNRGBALayout(outW, outH)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxDecodedImageBytes {
+		return nil, errDecodedImageTooLarge
+	}
+
+	pix, dw, dh, stride, err := libwebp.WebPDecodeWithConfig(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	img := &image.NRGBA{Pix: pix, Stride: stride, Rect: image.Rect(0, 0, dw, dh)}
+	if opts.FlipHorizontally {
+		mirrorNRGBA(img)
+	}
+	return img, nil
+}
+
+// decoderOptions sets the libwebp decoder options for o. Crop and Scale are
+// copied as is: a zero Scale dimension is left for libwebp to derive from
+// the aspect ratio, and the crop is only checked against the image bounds
+// by libwebp once the header is parsed.
+func (o *DecodeOptions) decoderOptions(options *libwebp.DecoderOptions) {
+	if crop := o.Crop; !crop.Empty() {
+		options.UseCropping = 1
+		options.CropLeft = int32(crop.Min.X)
+		options.CropTop = int32(crop.Min.Y)
+		options.CropWidth = int32(crop.Dx())
+		options.CropHeight = int32(crop.Dy())
+	}
+	if scale := o.Scale; scale != (image.Point{}) {
+		options.UseScaling = 1
+		options.ScaledWidth = int32(scale.X)
+		options.ScaledHeight = int32(scale.Y)
+	}
+	if o.FlipVertically {
+		options.Flip = 1
+	}
+	options.DitheringStrength = int32(o.DitheringStrength)
+	options.AlphaDitheringStrength = int32(o.AlphaDitheringStrength)
+	if o.NoFancyUpsampling {
+		options.NoFancyUpsampling = 1
+	}
+	if o.BypassFiltering {
+		options.BypassFiltering = 1
+	}
+	if o.UseThreads {
+		options.UseThreads = 1
+	}
+}
+
+// scaledSize returns the output size for a w by h source scaled to scale,
+// filling in a zero dimension from the aspect ratio (at least 1 pixel).
+func scaledSize(w, h int, scale image.Point) (int, int) {
+	switch {
+	case scale.X == 0:
+		return max(1, (w*scale.Y+h/2)/h), scale.Y
+	case scale.Y == 0:
+		return scale.X, max(1, (h*scale.X+w/2)/w)
+	}
+	return scale.X, scale.Y
+}
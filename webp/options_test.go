@@ -0,0 +1,314 @@
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// patternNRGBA returns an image whose every pixel is distinct, so that any
+// offset mistake shows up in a pixel comparison.
+func patternNRGBA(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 9), G: uint8(y * 13), B: uint8(x*y + 7), A: uint8(255 - x - y)})
+		}
+	}
+	return img
+}
+
+func TestDecodeWithOptionsCrop(t *testing.T) {
+	src := patternNRGBA(12, 10)
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	crop := image.Rect(3, 2, 9, 7)
+	img, err := DecodeWithOptions(bytes.NewReader(data), &DecodeOptions{Crop: crop})
+	if err != nil {
+		t.Fatalf("DecodeWithOptions() error = %v", err)
+	}
+	got := img.(*image.NRGBA)
+	if got.Rect != image.Rect(0, 0, 6, 5) {
+		t.Fatalf("bounds = %v, want %v", got.Rect, image.Rect(0, 0, 6, 5))
+	}
+	for y := range 5 {
+		for x := range 6 {
+			if g, w := got.NRGBAAt(x, y), src.NRGBAAt(x+3, y+2); g != w {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestDecodeWithOptionsNilMatchesDecode(t *testing.T) {
+	data, err := encodeLosslessBytes(patternNRGBA(7, 5))
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	got, err := DecodeWithOptions(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("DecodeWithOptions() error = %v", err)
+	}
+	want, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !bytes.Equal(got.(*image.NRGBA).Pix, want.(*image.NRGBA).Pix) {
+		t.Fatal("DecodeWithOptions(nil) differs from Decode")
+	}
+}
+
+func TestDefaultOptionsRoundTrip(t *testing.T) {
+	src := patternNRGBA(16, 12)
+	var withDefaults, withNil bytes.Buffer
+	if err := Encode(&withDefaults, src, DefaultEncodeOptions()); err != nil {
+		t.Fatalf("Encode(DefaultEncodeOptions()) error = %v", err)
+	}
+	if err := Encode(&withNil, src, nil); err != nil {
+		t.Fatalf("Encode(nil) error = %v", err)
+	}
+	if !bytes.Equal(withDefaults.Bytes(), withNil.Bytes()) {
+		t.Fatal("Encode(DefaultEncodeOptions()) differs from Encode(nil)")
+	}
+	bad := 7
+	if err := Encode(&bytes.Buffer{}, src, &EncodeOptions{Method: &bad}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Encode(Method=7) error = %v, want %v", err, ErrInvalidOption)
+	}
+
+	got, err := DecodeWithOptions(bytes.NewReader(withDefaults.Bytes()), DefaultDecodeOptions())
+	if err != nil {
+		t.Fatalf("DecodeWithOptions(DefaultDecodeOptions()) error = %v", err)
+	}
+	want, err := Decode(bytes.NewReader(withDefaults.Bytes()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.Bounds() != src.Rect || !bytes.Equal(got.(*image.NRGBA).Pix, want.(*image.NRGBA).Pix) {
+		t.Fatalf("DecodeWithOptions(DefaultDecodeOptions()) differs from Decode")
+	}
+}
+
+func TestDecodeWithOptionsRejectsCropOutsideImage(t *testing.T) {
+	data, err := encodeLosslessBytes(patternNRGBA(8, 8))
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	for _, crop := range []image.Rectangle{
+		image.Rect(4, 4, 9, 8),
+		image.Rect(-1, 0, 4, 4),
+		image.Rect(8, 8, 10, 10),
+	} {
+		if _, err := DecodeWithOptions(bytes.NewReader(data), &DecodeOptions{Crop: crop}); !errors.Is(err, ErrInvalidCrop) {
+			t.Errorf("crop %v error = %v, want %v", crop, err, ErrInvalidCrop)
+		}
+	}
+}
+
+func TestDecodeWithOptionsScale(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for y := range 100 {
+		for x := range 200 {
+			// Left half red, right half blue.
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 100 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	for _, tt := range []struct {
+		scale image.Point
+		want  image.Rectangle
+	}{
+		{image.Pt(100, 50), image.Rect(0, 0, 100, 50)},
+		{image.Pt(100, 0), image.Rect(0, 0, 100, 50)},
+		{image.Pt(0, 25), image.Rect(0, 0, 50, 25)},
+		{image.Pt(300, 30), image.Rect(0, 0, 300, 30)},
+	} {
+		img, err := DecodeWithOptions(bytes.NewReader(data), &DecodeOptions{Scale: tt.scale})
+		if err != nil {
+			t.Fatalf("Scale %v: error = %v", tt.scale, err)
+		}
+		got := img.(*image.NRGBA)
+		if got.Rect != tt.want {
+			t.Fatalf("Scale %v: bounds = %v, want %v", tt.scale, got.Rect, tt.want)
+		}
+		w := got.Rect.Dx()
+		if l, r := got.NRGBAAt(w/4, 0), got.NRGBAAt(3*w/4, 0); l.R < 250 || r.B < 250 {
+			t.Fatalf("Scale %v: left %v, right %v, want red then blue", tt.scale, l, r)
+		}
+	}
+
+	if _, err := DecodeWithOptions(bytes.NewReader(data), &DecodeOptions{Scale: image.Pt(-1, 10)}); !errors.Is(err, ErrInvalidScale) {
+		t.Fatalf("negative Scale error = %v, want %v", err, ErrInvalidScale)
+	}
+}
+
+func TestDecodeWithOptionsCropThenScale(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 256, 128))
+	for y := range 128 {
+		for x := range 256 {
+			// Left half red, right half blue.
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 128 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	leftHalf := image.Rect(0, 0, 128, 128)
+	for _, tt := range []struct {
+		scale image.Point
+		want  image.Rectangle
+	}{
+		{image.Pt(64, 64), image.Rect(0, 0, 64, 64)},
+		// The aspect ratio comes from the crop, not the 2:1 source.
+		{image.Pt(32, 0), image.Rect(0, 0, 32, 32)},
+	} {
+		img, err := decodeWithOptions(data, &DecodeOptions{Crop: leftHalf, Scale: tt.scale})
+		if err != nil {
+			t.Fatalf("Scale %v: error = %v", tt.scale, err)
+		}
+		if img.Rect != tt.want {
+			t.Fatalf("Scale %v: bounds = %v, want %v", tt.scale, img.Rect, tt.want)
+		}
+		for _, p := range []image.Point{{0, 0}, {tt.want.Dx() - 1, tt.want.Dy() - 1}} {
+			if c := img.NRGBAAt(p.X, p.Y); c.R < 250 || c.B > 5 {
+				t.Fatalf("Scale %v: pixel %v = %v, want red from the cropped half", tt.scale, p, c)
+			}
+		}
+	}
+
+	// The crop is in source coordinates, so it may exceed the scaled size.
+	if _, err := decodeWithOptions(data, &DecodeOptions{Crop: image.Rect(128, 0, 256, 128), Scale: image.Pt(64, 64)}); err != nil {
+		t.Fatalf("right-half crop error = %v", err)
+	}
+	if _, err := decodeWithOptions(data, &DecodeOptions{Crop: image.Rect(200, 0, 300, 128), Scale: image.Pt(64, 64)}); !errors.Is(err, ErrInvalidCrop) {
+		t.Fatalf("out-of-bounds crop error = %v, want %v", err, ErrInvalidCrop)
+	}
+}
+
+func TestDecodeWithOptionsFlipHorizontally(t *testing.T) {
+	src := patternNRGBA(5, 4)
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	flipped, err := decodeWithOptions(data, &DecodeOptions{FlipHorizontally: true})
+	if err != nil {
+		t.Fatalf("decodeWithOptions() error = %v", err)
+	}
+	if got, want := flipped.NRGBAAt(0, 0), src.NRGBAAt(4, 0); got != want {
+		t.Fatalf("pixel (0, 0) = %v, want source (4, 0) %v", got, want)
+	}
+	if got, want := flipped.NRGBAAt(4, 0), src.NRGBAAt(0, 0); got != want {
+		t.Fatalf("pixel (4, 0) = %v, want source (0, 0) %v", got, want)
+	}
+
+	rotated, err := decodeWithOptions(data, &DecodeOptions{FlipHorizontally: true, FlipVertically: true})
+	if err != nil {
+		t.Fatalf("decodeWithOptions() error = %v", err)
+	}
+	for y := range 4 {
+		for x := range 5 {
+			if got, want := rotated.NRGBAAt(x, y), src.NRGBAAt(4-x, 3-y); got != want {
+				t.Fatalf("rotated pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeWithOptionsFlipVertically(t *testing.T) {
+	data, err := encodeLosslessBytes(patternNRGBA(9, 6))
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	plain, err := decodeWithOptions(data, nil)
+	if err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	flipped, err := decodeWithOptions(data, &DecodeOptions{FlipVertically: true})
+	if err != nil {
+		t.Fatalf("flipped decode error = %v", err)
+	}
+
+	row := func(img *image.NRGBA, y int) []byte { return img.Pix[y*img.Stride : y*img.Stride+9*4] }
+	if !bytes.Equal(row(flipped, 0), row(plain, 5)) || !bytes.Equal(row(flipped, 5), row(plain, 0)) {
+		t.Fatal("rows 0 and H-1 did not swap")
+	}
+	if bytes.Equal(row(plain, 0), row(plain, 5)) {
+		t.Fatal("fixture rows 0 and H-1 are identical")
+	}
+}
+
+func TestDecodeWithOptionsDithering(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 128, 64))
+	for y := range 64 {
+		for x := range 128 {
+			v := uint8(64 + x/4)
+			src.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	// libwebp only dithers finely quantized chroma without AC coefficients:
+	// a high-quality gray gradient qualifies.
+	var buf bytes.Buffer
+	if err := Encode(&buf, src, &EncodeOptions{Quality: 100}); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	data := buf.Bytes()
+
+	plain, err := decodeWithOptions(data, nil)
+	if err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	dithered, err := decodeWithOptions(data, &DecodeOptions{DitheringStrength: 100})
+	if err != nil {
+		t.Fatalf("dithered decode error = %v", err)
+	}
+	if bytes.Equal(plain.Pix, dithered.Pix) {
+		t.Fatal("DitheringStrength 100 produced the same pixels as no dithering")
+	}
+
+	for _, opts := range []DecodeOptions{{DitheringStrength: 101}, {AlphaDitheringStrength: -1}} {
+		if _, err := decodeWithOptions(data, &opts); !errors.Is(err, ErrInvalidDecodeOption) {
+			t.Errorf("%+v error = %v, want %v", opts, err, ErrInvalidDecodeOption)
+		}
+	}
+}
+
+func TestDecodeWithOptionsFastPaths(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, patternNRGBA(37, 23), &EncodeOptions{Quality: 50}); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	for _, opts := range []DecodeOptions{
+		{NoFancyUpsampling: true},
+		{BypassFiltering: true},
+		{NoFancyUpsampling: true, BypassFiltering: true},
+		{UseThreads: true},
+	} {
+		img, err := decodeWithOptions(buf.Bytes(), &opts)
+		if err != nil {
+			t.Fatalf("%+v: error = %v", opts, err)
+		}
+		if img.Rect != image.Rect(0, 0, 37, 23) {
+			t.Fatalf("%+v: bounds = %v, want 37x23", opts, img.Rect)
+		}
+	}
+}
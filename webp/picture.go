@@ -0,0 +1,102 @@
+package webp
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// withPicture imports img into an ARGB libwebp picture, calls fn and frees
+// the picture afterwards.
+func withPicture(img *image.NRGBA, fn func(pic *libwebp.Picture) error) error {
+	var pic libwebp.Picture
+	ok, err := libwebp.WebPPictureInit(&pic)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return libwebp.ErrEncodeFailed
+	}
+	pic.UseArgb = 1
+	pic.Width = int32(img.Rect.Dx())
+	pic.Height = int32(img.Rect.Dy())
+	defer libwebp.WebPPictureFree(&pic)
+
+	ok, err = libwebp.WebPPictureImportRGBA(&pic, img.Pix, img.Stride)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return libwebp.ErrEncodeFailed
+	}
+	return fn(&pic)
+}
+
+// HasTransparency reports whether img contains any pixel that is not fully
+// opaque. The check runs in libwebp; if the library cannot be used it falls
+// back to the image's own Opaque method or a pixel scan.
+func HasTransparency(img image.Image) bool {
+	if b := img.Bounds(); b.Empty() {
+		return false
+	}
+
+	var transparent bool
+	err := withPicture(toNRGBA(img), func(pic *libwebp.Picture) error {
+		var err error
+		transparent, err = libwebp.WebPPictureHasTransparency(pic)
+		return err
+	})
+	if err == nil {
+		return transparent
+	}
+
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FlattenOnto composites img onto the solid background color bg and returns
+// a fully opaque copy. The alpha of bg is ignored.
+func FlattenOnto(img image.Image, bg color.Color) image.Image {
+	src := toNRGBA(img)
+	out := image.NewNRGBA(image.Rect(0, 0, src.Rect.Dx(), src.Rect.Dy()))
+	if out.Rect.Empty() {
+		return out
+	}
+
+	c := color.NRGBAModel.Convert(bg).(color.NRGBA)
+	backgroundRGB := uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+	err := withPicture(src, func(pic *libwebp.Picture) error {
+		if err := libwebp.WebPBlendAlpha(pic, backgroundRGB); err != nil {
+			return err
+		}
+		return libwebp.PictureARGBToRGBA(pic, out.Pix, out.Stride)
+	})
+	if err != nil {
+		flattenNRGBA(out, src, c)
+	}
+	return out
+}
+
+// flattenNRGBA is the pure-Go fallback of FlattenOnto.
+func flattenNRGBA(dst, src *image.NRGBA, bg color.NRGBA) {
+	blend := func(c, b, a uint8) uint8 {
+		return uint8((uint32(c)*uint32(a) + uint32(b)*(255-uint32(a)) + 127) / 255)
+	}
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			p := src.NRGBAAt(src.Rect.Min.X+x, src.Rect.Min.Y+y)
+			dst.SetNRGBA(x, y, color.NRGBA{R: blend(p.R, bg.R, p.A), G: blend(p.G, bg.G, p.A), B: blend(p.B, bg.B, p.A), A: 255})
+		}
+	}
+}
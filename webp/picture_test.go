@@ -0,0 +1,38 @@
+package webp
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestHasTransparency(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 10, G: 20, B: 30, A: 255}), image.Point{}, draw.Src)
+	if HasTransparency(img) {
+		t.Fatal("HasTransparency(opaque) = true")
+	}
+
+	img.SetRGBA(5, 6, color.RGBA{R: 5, G: 10, B: 15, A: 128})
+	if !HasTransparency(img) {
+		t.Fatal("HasTransparency(partly transparent) = false")
+	}
+}
+
+func TestFlattenOnto(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.NRGBA{R: 255, A: 128}), image.Point{}, draw.Src)
+
+	got := FlattenOnto(src, color.White)
+	if HasTransparency(got) {
+		t.Fatal("FlattenOnto() result has transparency")
+	}
+	c := color.NRGBAModel.Convert(got.At(2, 2)).(color.NRGBA)
+	want := color.NRGBA{R: 255, G: 127, B: 127, A: 255}
+	for _, d := range []int{int(c.R) - int(want.R), int(c.G) - int(want.G), int(c.B) - int(want.B)} {
+		if d < -2 || d > 2 {
+			t.Fatalf("FlattenOnto() pixel = %v, want ~%v", c, want)
+		}
+	}
+}
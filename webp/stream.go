@@ -0,0 +1,108 @@
+package webp
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// streamChunkSize is how much DecodeStream reads per call to r.
+const streamChunkSize = 32 << 10
+
+// streamHeaderChunkSize is how much DecodeStreamConfig reads per call to r;
+// the WebP headers that carry the dimensions fit in the first few dozen
+// bytes.
+const streamHeaderChunkSize = 64
+
+// DecodeStream decodes a WebP image from r as it is read, feeding each chunk
+// to an IncrementalDecoder so that decoding overlaps with slow I/O. It
+// returns io.ErrUnexpectedEOF if r ends before the image is complete.
+func DecodeStream(r io.Reader) (image.Image, error) {
+	return DecodeContext(context.Background(), r)
+}
+
+// DecodeContext is like DecodeStream but stops when ctx is done, returning
+// ctx.Err(). ctx is checked before each read of at most 32 KiB from r, so a
+// cancelled decode returns once the read in progress completes.
+func DecodeContext(ctx context.Context, r io.Reader) (image.Image, error) {
+	dec, err := NewIncrementalDecoder(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := dec.Write(buf[:n]); werr != nil {
+				return nil, werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	img, lastRow, err := dec.Image()
+	if errors.Is(err, ErrNotEnoughData) || (err == nil && lastRow < img.Bounds().Dy()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// DecodeStreamConfig returns the configuration of the WebP image in r, as
+// DecodeConfig does, as soon as its header has been read, leaving the rest
+// of r unread. It returns io.ErrUnexpectedEOF if r ends before the header
+// is complete.
+func DecodeStreamConfig(r io.Reader) (image.Config, error) {
+	features, status, err := readFeatures(r, streamHeaderChunkSize)
+	if err != nil {
+		return image.Config{}, err
+	}
+	switch status {
+	case libwebp.VP8StatusOK:
+		return configFromFeatures(features), nil
+	case libwebp.VP8StatusNotEnoughData:
+		return image.Config{}, io.ErrUnexpectedEOF
+	default:
+		return image.Config{}, libwebp.ErrorFromStatus(status)
+	}
+}
+
+// readFeatures reads r chunkSize bytes at a time until WebPGetFeatures can
+// parse the prefix read so far, and returns its result. It stops reading as
+// soon as the status is anything but VP8StatusNotEnoughData, which is only
+// returned once r is exhausted.
+func readFeatures(r io.Reader, chunkSize int) (libwebp.BitstreamFeatures, libwebp.VP8StatusCode, error) {
+	var prefix []byte
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		prefix = append(prefix, buf[:n]...)
+		if n > 0 {
+			features, status, ferr := libwebp.WebPGetFeatures(prefix)
+			if ferr != nil || status != libwebp.VP8StatusNotEnoughData {
+				return features, status, ferr
+			}
+		}
+		if err == io.EOF {
+			return libwebp.BitstreamFeatures{}, libwebp.VP8StatusNotEnoughData, nil
+		}
+		if err != nil {
+			return libwebp.BitstreamFeatures{}, 0, err
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package webp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// slowReader returns at most step bytes per Read and records how many bytes
+// have been consumed.
+type slowReader struct {
+	data []byte
+	step int
+	read int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.read == len(r.data) {
+		return 0, io.EOF
+	}
+	n := copy(p[:min(len(p), r.step)], r.data[r.read:])
+	r.read += n
+	return n, nil
+}
+
+func streamFixture(t *testing.T) (*image.NRGBA, []byte) {
+	t.Helper()
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := range 48 {
+		for x := range 64 {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: uint8(x * y), A: 255})
+		}
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+	return src, data
+}
+
+func TestDecodeStreamConfigStopsAtHeader(t *testing.T) {
+	_, data := streamFixture(t)
+	r := &slowReader{data: data, step: 7}
+
+	cfg, err := DecodeStreamConfig(r)
+	if err != nil {
+		t.Fatalf("DecodeStreamConfig() error = %v", err)
+	}
+	if cfg.Width != 64 || cfg.Height != 48 {
+		t.Fatalf("DecodeStreamConfig() = %dx%d, want 64x48", cfg.Width, cfg.Height)
+	}
+	if r.read >= len(data) {
+		t.Fatalf("DecodeStreamConfig() read all %d bytes before returning", len(data))
+	}
+
+	if _, err := DecodeStreamConfig(bytes.NewReader(data[:10])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("DecodeStreamConfig(truncated) error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+// headerOnlyReader yields the first n bytes of data, then fails, standing in
+// for a network body whose image data must not be consumed.
+type headerOnlyReader struct {
+	data []byte
+	n    int
+	done bool
+}
+
+var errBodyRead = errors.New("read past the header")
+
+func (r *headerOnlyReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, errBodyRead
+	}
+	r.done = true
+	return copy(p, r.data[:r.n]), nil
+}
+
+func TestDecodeConfigReadsOnlyHeader(t *testing.T) {
+	_, data := streamFixture(t)
+	cfg, err := DecodeConfig(&headerOnlyReader{data: data, n: 64})
+	if err != nil {
+		t.Fatalf("DecodeConfig() error = %v", err)
+	}
+	if cfg.Width != 64 || cfg.Height != 48 {
+		t.Fatalf("DecodeConfig() = %dx%d, want 64x48", cfg.Width, cfg.Height)
+	}
+
+	if _, err := DecodeConfig(&headerOnlyReader{data: data, n: 10}); !errors.Is(err, errBodyRead) {
+		t.Fatalf("DecodeConfig(short header) error = %v, want %v", err, errBodyRead)
+	}
+	if _, err := DecodeConfig(bytes.NewReader(data[:10])); !errors.Is(err, libwebp.ErrInvalidData) {
+		t.Fatalf("DecodeConfig(truncated) error = %v, want %v", err, libwebp.ErrInvalidData)
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	src, data := streamFixture(t)
+
+	img, err := DecodeStream(&slowReader{data: data, step: 13})
+	if err != nil {
+		t.Fatalf("DecodeStream() error = %v", err)
+	}
+	if got := img.(*image.NRGBA); got.Rect != src.Rect || !bytes.Equal(got.Pix, src.Pix) {
+		t.Fatal("DecodeStream() does not match the source image")
+	}
+
+	if _, err := DecodeStream(bytes.NewReader(data[:len(data)/2])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("DecodeStream(truncated) error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+// cancelingReader is a slowReader that cancels its context once it has
+// handed out after bytes.
+type cancelingReader struct {
+	slowReader
+	after  int
+	cancel context.CancelFunc
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	n, err := r.slowReader.Read(p)
+	if r.read >= r.after {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestDecodeContext(t *testing.T) {
+	src, data := streamFixture(t)
+
+	img, err := DecodeContext(context.Background(), &slowReader{data: data, step: 100})
+	if err != nil {
+		t.Fatalf("DecodeContext() error = %v", err)
+	}
+	if got := img.(*image.NRGBA); !bytes.Equal(got.Pix, src.Pix) {
+		t.Fatal("DecodeContext() does not match the source image")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &cancelingReader{slowReader: slowReader{data: data, step: 100}, after: len(data) / 2, cancel: cancel}
+	if _, err := DecodeContext(ctx, r); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DecodeContext(canceled) error = %v, want %v", err, context.Canceled)
+	}
+	if r.read >= len(data) {
+		t.Fatalf("DecodeContext(canceled) read all %d bytes", len(data))
+	}
+}
@@ -0,0 +1,151 @@
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"image"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+var (
+	// ErrInvalidRotation indicates a rotation angle other than 90, 180 or 270.
+	ErrInvalidRotation = errors.New("webp: rotation must be 90, 180 or 270 degrees")
+	// ErrMaskSize indicates an alpha mask whose dimensions do not match the image.
+	ErrMaskSize = errors.New("webp: alpha mask does not match image dimensions")
+)
+
+// Rotate decodes data, rotates the pixels clockwise by degrees (90, 180 or
+// 270) and re-encodes the result as lossless WebP.
+//
+// WebP has no bitstream-level lossless rotation like JPEG, so the image is
+// always re-encoded; lossless output preserves the decoded pixels exactly,
+// but a lossy source is not restored to its original bytes or size.
+func Rotate(data []byte, degrees int) ([]byte, error) {
+	if degrees != 90 && degrees != 180 && degrees != 270 {
+		return nil, ErrInvalidRotation
+	}
+
+	src, err := decodeNRGBA(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeLosslessBytes(rotateNRGBA(src, degrees))
+}
+
+// decodeNRGBA decodes an in-memory WebP to *image.NRGBA.
+func decodeNRGBA(data []byte) (*image.NRGBA, error) {
+	img, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return img.(*image.NRGBA), nil
+}
+
+func encodeLosslessBytes(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := EncodeLossless(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rotateNRGBA returns src rotated clockwise by 90, 180 or 270 degrees.
+func rotateNRGBA(src *image.NRGBA, degrees int) *image.NRGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dstW, dstH := w, h
+	if degrees != 180 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := range h {
+		row := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		for x := range w {
+			var dx, dy int
+			switch degrees {
+			case 90:
+				dx, dy = h-1-y, x
+			case 180:
+				dx, dy = w-1-x, h-1-y
+			default:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], row[x*4:x*4+4])
+		}
+	}
+	return dst
+}
+
+// Flip decodes data, mirrors it horizontally (left-right) or vertically
+// (top-bottom) and re-encodes the result as lossless WebP, preserving alpha.
+//
+// Like Rotate, this is a pixel-level operation followed by a lossless
+// re-encode, not a bitstream-level transform. Vertical flips are performed by
+// libwebp during decode, avoiding a separate pass.
+func Flip(data []byte, horizontal bool) ([]byte, error) {
+	var config libwebp.DecoderConfig
+	ok, err := libwebp.WebPInitDecoderConfig(&config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrDecodeFailed
+	}
+	config.Output.Colorspace = int32(libwebp.ModeRGBA)
+	if !horizontal {
+		config.Options.Flip = 1
+	}
+
+	pix, w, h, stride, err := libwebp.WebPDecodeWithConfig(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	img := &image.NRGBA{Pix: pix, Stride: stride, Rect: image.Rect(0, 0, w, h)}
+	if horizontal {
+		mirrorNRGBA(img)
+	}
+	return encodeLosslessBytes(img)
+}
+
+// mirrorNRGBA reverses the pixel order of every row of img in place.
+func mirrorNRGBA(img *image.NRGBA) {
+	w := img.Rect.Dx()
+	for y := range img.Rect.Dy() {
+		row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		for l, r := 0, (w-1)*4; l < r; l, r = l+4, r-4 {
+			var tmp [4]byte
+			copy(tmp[:], row[l:l+4])
+			copy(row[l:l+4], row[r:r+4])
+			copy(row[r:r+4], tmp[:])
+		}
+	}
+}
+
+// ApplyAlphaMask decodes the WebP in rgbData, replaces its alpha channel with
+// mask and re-encodes the result as lossless RGBA WebP.
+//
+// mask holds one 8-bit alpha value per pixel, row by row. Its stride is
+// len(mask)/height, so rows may be padded; the stride must be at least the
+// image width and len(mask) a multiple of the height, otherwise ErrMaskSize
+// is returned.
+func ApplyAlphaMask(rgbData []byte, mask []byte) ([]byte, error) {
+	img, err := decodeNRGBA(rgbData)
+	if err != nil {
+		return nil, err
+	}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if len(mask)%h != 0 || len(mask)/h < w {
+		return nil, ErrMaskSize
+	}
+	maskStride := len(mask) / h
+
+	for y := range h {
+		row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		alpha := mask[y*maskStride : y*maskStride+w]
+		for x, a := range alpha {
+			row[x*4+3] = a
+		}
+	}
+	return encodeLosslessBytes(img)
+}
@@ -0,0 +1,154 @@
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	data, src := testWebP(t) // 3x2
+	tests := []struct {
+		degrees    int
+		w, h       int
+		srcX, srcY int // source of destination pixel (0, 0)
+	}{
+		{degrees: 90, w: 2, h: 3, srcX: 0, srcY: 1},
+		{degrees: 180, w: 3, h: 2, srcX: 2, srcY: 1},
+		{degrees: 270, w: 2, h: 3, srcX: 2, srcY: 0},
+	}
+	for _, tt := range tests {
+		out, err := Rotate(data, tt.degrees)
+		if err != nil {
+			t.Fatalf("Rotate(%d) error = %v", tt.degrees, err)
+		}
+		got, err := decodeNRGBA(out)
+		if err != nil {
+			t.Fatalf("decode Rotate(%d) output: %v", tt.degrees, err)
+		}
+		if got.Rect != image.Rect(0, 0, tt.w, tt.h) {
+			t.Fatalf("Rotate(%d) bounds = %v, want %dx%d", tt.degrees, got.Rect, tt.w, tt.h)
+		}
+		if got.NRGBAAt(0, 0) != src.NRGBAAt(tt.srcX, tt.srcY) {
+			t.Fatalf("Rotate(%d) (0,0) = %v, want %v", tt.degrees, got.NRGBAAt(0, 0), src.NRGBAAt(tt.srcX, tt.srcY))
+		}
+	}
+}
+
+func TestRotateRejectsInvalidDegrees(t *testing.T) {
+	data, _ := testWebP(t)
+	for _, degrees := range []int{0, 45, -90, 360} {
+		if _, err := Rotate(data, degrees); !errors.Is(err, ErrInvalidRotation) {
+			t.Fatalf("Rotate(%d) error = %v, want %v", degrees, err, ErrInvalidRotation)
+		}
+	}
+}
+
+func TestFlip(t *testing.T) {
+	data, src := testWebP(t) // 3x2
+	for _, horizontal := range []bool{true, false} {
+		out, err := Flip(data, horizontal)
+		if err != nil {
+			t.Fatalf("Flip(%v) error = %v", horizontal, err)
+		}
+		got, err := decodeNRGBA(out)
+		if err != nil {
+			t.Fatalf("decode Flip(%v) output: %v", horizontal, err)
+		}
+		for y := range 2 {
+			for x := range 3 {
+				sx, sy := x, 1-y
+				if horizontal {
+					sx, sy = 2-x, y
+				}
+				if got.NRGBAAt(x, y) != src.NRGBAAt(sx, sy) {
+					t.Fatalf("Flip(%v) (%d,%d) = %v, want %v", horizontal, x, y, got.NRGBAAt(x, y), src.NRGBAAt(sx, sy))
+				}
+			}
+		}
+	}
+}
+
+func TestApplyAlphaMask(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < len(src.Pix); i += 4 {
+		src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = 10, 20, 30, 255
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	// Stride 4 with one byte of padding per row.
+	mask := []byte{255, 128, 1, 0xee, 64, 32, 16, 0xee}
+	out, err := ApplyAlphaMask(data, mask)
+	if err != nil {
+		t.Fatalf("ApplyAlphaMask() error = %v", err)
+	}
+	got, err := decodeNRGBA(out)
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	for y := range 2 {
+		for x := range 3 {
+			if a, want := got.NRGBAAt(x, y).A, mask[y*4+x]; a != want {
+				t.Fatalf("alpha at (%d,%d) = %d, want %d", x, y, a, want)
+			}
+		}
+	}
+
+	for _, bad := range [][]byte{make([]byte, 5), make([]byte, 4), nil} {
+		if _, err := ApplyAlphaMask(data, bad); !errors.Is(err, ErrMaskSize) {
+			t.Fatalf("ApplyAlphaMask(len %d) error = %v, want %v", len(bad), err, ErrMaskSize)
+		}
+	}
+}
+
+func TestDecodeSharpened(t *testing.T) {
+	// A vertical edge: columns 0-2 are dark, 3-5 bright.
+	src := image.NewNRGBA(image.Rect(0, 0, 6, 4))
+	for y := range 4 {
+		for x := range 6 {
+			v := uint8(50)
+			if x >= 3 {
+				v = 200
+			}
+			src.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 200})
+		}
+	}
+	data, err := encodeLosslessBytes(src)
+	if err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	got, err := DecodeSharpened(data, 1)
+	if err != nil {
+		t.Fatalf("DecodeSharpened() error = %v", err)
+	}
+	if c := got.NRGBAAt(0, 1); c != src.NRGBAAt(0, 1) {
+		t.Fatalf("flat pixel = %v, want unchanged %v", c, src.NRGBAAt(0, 1))
+	}
+	if c := got.NRGBAAt(2, 1); c.R >= 50 || c.A != 200 {
+		t.Fatalf("dark edge pixel = %v, want R < 50 and alpha kept", c)
+	}
+	if c := got.NRGBAAt(3, 1); c.R <= 200 || c.A != 200 {
+		t.Fatalf("bright edge pixel = %v, want R > 200 and alpha kept", c)
+	}
+
+	plain, err := DecodeSharpened(data, 0)
+	if err != nil {
+		t.Fatalf("DecodeSharpened(0) error = %v", err)
+	}
+	if !bytes.Equal(plain.Pix, src.Pix) {
+		t.Fatal("DecodeSharpened(0) changed the pixels")
+	}
+
+	for _, bad := range []float64{-0.1, MaxSharpenAmount + 1, math.NaN()} {
+		if _, err := DecodeSharpened(data, bad); !errors.Is(err, ErrInvalidSharpenAmount) {
+			t.Fatalf("DecodeSharpened(%v) error = %v, want %v", bad, err, ErrInvalidSharpenAmount)
+		}
+	}
+}
@@ -2,23 +2,109 @@
 package webp
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"io"
 	"math"
+	"sync/atomic"
 
 	"github.com/bnema/purego-webp/libwebp"
 )
 
 type EncodeOptions struct {
+	// Quality is the lossy quality in [0, 100]; 0 selects the default of
+	// 75. Values outside the range, and NaN, are rejected with
+	// ErrInvalidQuality rather than clamped.
 	Quality  float32
 	Lossless bool
+	// Exact preserves the RGB values under fully transparent pixels.
+	Exact bool
+	// CleanupTransparent flattens fully transparent areas before encoding so
+	// they compress better. It is ignored when Exact is set.
+	CleanupTransparent bool
+	// AlphaFiltering selects the predictive filter for the lossy alpha plane:
+	// 0 none, 1 fast, 2 best. Nil keeps the libwebp default (fast). Images
+	// with structured alpha, such as UI elements, benefit from 2.
+	AlphaFiltering *int
+	// AlphaCompression selects how the lossy alpha plane is stored: 0
+	// uncompressed, 1 lossless-compressed. Nil keeps the libwebp default
+	// (1).
+	AlphaCompression *int
+	// UseSharpYuv uses the slower, more accurate RGB->YUV conversion for
+	// lossy output. It has no effect on *image.YCbCr sources, which need no
+	// conversion. Requires libwebp 0.6.0.
+	UseSharpYuv bool
+	// NearLossless applies near-lossless preprocessing to lossless output:
+	// 0 is the strongest, 100 disables it. Nil keeps the libwebp default
+	// (100). Requires libwebp 0.5.0.
+	NearLossless *int
+	// Method trades encoding speed for size, in [0, 6]: 0 is the fastest,
+	// 6 the smallest output. Nil keeps the libwebp default (4).
+	Method *int
+	// PartitionLimit caps the size of the first lossy partition, in
+	// [0, 100]: 0 imposes no limit, 100 the strictest one, at some quality
+	// cost. Some memory-limited hardware decoders need it.
+	PartitionLimit int
+	// EmulateJpegSize maps Quality so that lossy output is about the size
+	// of a JPEG of the same quality, easing migrations that tuned JPEG
+	// quality settings.
+	EmulateJpegSize bool
+	// TargetSize makes the lossy encoder search for the quality giving an
+	// output of about this many bytes, overriding Quality. 0 disables it.
+	TargetSize int
+	// QMin and QMax bound the quality the TargetSize search may pick, in
+	// [0, 100] with QMin <= QMax. Zero keeps the libwebp defaults (0 and
+	// 100). Requires libwebp 1.2.0; older releases ignore them.
+	QMin, QMax int
+	// UseDeltaPalette enables libwebp's experimental delta-palette mode for
+	// lossless output, which can help some synthetic images; libwebp builds
+	// without experimental features ignore it. It requires Lossless.
+	// Requires libwebp 0.5.0.
+	UseDeltaPalette bool
+}
+
+// DefaultEncodeOptions returns the options matching libwebp's own defaults:
+// lossy at Quality 75 with Method 4, every other field at its zero value.
+// Encoding with them is equivalent to a nil *EncodeOptions; they exist as a
+// starting point to tweak.
+func DefaultEncodeOptions() *EncodeOptions {
+	method := 4
+	return &EncodeOptions{Quality: 75, Method: &method}
 }
 
 const maxDecodedImageBytes = 1 << 30
 
-var errDecodedImageTooLarge = errors.New("webp: decoded image exceeds size limit")
+// MaxDimension is the largest width or height a WebP image can have.
+const MaxDimension = 16383
+
+var (
+	// ErrInvalidOption indicates an EncodeOptions field is out of range.
+	ErrInvalidOption = errors.New("webp: invalid encode option")
+	// ErrInvalidQuality indicates an EncodeOptions.Quality outside [0, 100]
+	// or NaN. It matches ErrInvalidOption.
+	ErrInvalidQuality = fmt.Errorf("%w: quality must be in [0, 100]", ErrInvalidOption)
+	// ErrImageTooLarge indicates the image exceeds MaxDimension or the
+	// configured encode pixel limit.
+	ErrImageTooLarge = errors.New("webp: image too large")
+
+	errDecodedImageTooLarge = errors.New("webp: decoded image exceeds size limit")
+)
+
+var maxEncodePixels atomic.Int64
+
+// SetMaxEncodePixels limits the number of pixels (width*height) accepted by
+// Encode. Larger images are rejected with ErrImageTooLarge before any pixel
+// conversion or libwebp call. A value <= 0 removes the limit (the default).
+func SetMaxEncodePixels(n int) {
+	if n < 0 {
+		n = 0
+	}
+	maxEncodePixels.Store(int64(n))
+}
 
 func init() {
 	image.RegisterFormat("webp", "RIFF????WEBPVP8", Decode, DecodeConfig)
@@ -46,6 +132,9 @@ func Decode(r io.Reader) (image.Image, error) {
 		return nil, errDecodedImageTooLarge
 	}
 
+	// Decode straight into the image's pixels, with no intermediate libwebp
+	// buffer. A fresh NRGBA has stride w*4, but guard anyway so that a
+	// different layout fails rather than being decoded into.
 	img := image.NewNRGBA(image.Rect(0, 0, w, h))
 	if img.Stride != stride || len(img.Pix) != size {
 		return nil, errDecodedImageTooLarge
@@ -56,30 +145,96 @@ func Decode(r io.Reader) (image.Image, error) {
 	return img, nil
 }
 
-// DecodeConfig returns image metadata for a WebP image from r.
-func DecodeConfig(r io.Reader) (image.Config, error) {
+// DecodeRGBA reads a WebP image from r and returns it with premultiplied
+// alpha, as produced by libwebp's rgbA output mode. The result can be drawn
+// with image/draw without a conversion pass.
+func DecodeRGBA(r io.Reader) (*image.RGBA, error) {
 	b, err := io.ReadAll(r)
 	if err != nil {
-		return image.Config{}, err
+		return nil, err
 	}
 
 	w, h, ok, err := libwebp.WebPGetInfo(b)
 	if err != nil {
-		return image.Config{}, err
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrInvalidData
+	}
+	_, size, err := decodeNRGBALayout(w, h)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxDecodedImageBytes {
+		return nil, errDecodedImageTooLarge
+	}
+
+	var config libwebp.DecoderConfig
+	ok, err = libwebp.WebPInitDecoderConfig(&config)
+	if err != nil {
+		return nil, err
 	}
 	if !ok {
-		return image.Config{}, libwebp.ErrInvalidData
+		return nil, libwebp.ErrDecodeFailed
 	}
+	config.Output.Colorspace = int32(libwebp.ModergbA)
 
-	return image.Config{
-		ColorModel: color.NRGBAModel,
-		Width:      w,
-		Height:     h,
-	}, nil
+	pix, dw, dh, stride, err := libwebp.WebPDecodeWithConfig(b, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &image.RGBA{Pix: pix, Stride: stride, Rect: image.Rect(0, 0, dw, dh)}, nil
 }
 
-// Encode writes src as WebP to w using the provided options.
+// configPeekSize is how much DecodeConfig reads per call to r while looking
+// for the headers that carry the dimensions.
+const configPeekSize = 4 << 10
+
+// DecodeConfig returns image metadata for a WebP image from r. The color
+// model is color.NRGBAModel when the image has alpha and color.RGBAModel
+// otherwise. Only the start of r is read, a few kilobytes at a time until
+// the headers parse, so the image data is not buffered.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	features, status, err := readFeatures(r, configPeekSize)
+	if err != nil {
+		return image.Config{}, err
+	}
+	if status != libwebp.VP8StatusOK {
+		return image.Config{}, fmt.Errorf("%w: %w", libwebp.ErrInvalidData, libwebp.ErrorFromStatus(status))
+	}
+	return configFromFeatures(features), nil
+}
+
+// configFromFeatures returns the image.Config for features. Images without
+// alpha report color.RGBAModel so that callers choosing a channel layout
+// can tell them apart; both models describe an opaque image identically.
+func configFromFeatures(features libwebp.BitstreamFeatures) image.Config {
+	model := color.RGBAModel
+	if features.HasAlpha {
+		model = color.NRGBAModel
+	}
+	return image.Config{ColorModel: model, Width: features.Width, Height: features.Height}
+}
+
+// Encode writes src as WebP to w using the provided options. Encodes that
+// need the advanced options stream libwebp's output into w chunk by chunk
+// instead of buffering the whole file.
+//
+// A lossy encode of an *image.YCbCr with 4:2:0, 4:2:2 or 4:4:4 subsampling
+// (such as a decoded JPEG) feeds its planes to libwebp directly, skipping
+// the conversion to RGB and back.
 func Encode(w io.Writer, src image.Image, opts *EncodeOptions) error {
+	if err := checkEncodeBounds(src.Bounds()); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	// YCbCr sources skip the RGB round trip, which needs the advanced
+	// encoder.
+	if _, ok := encodesYCbCrDirectly(src, opts); ok || opts.advanced() {
+		return encodeAdvanced(w, src, opts, encodeHooks{})
+	}
 	nrgba := toNRGBA(src)
 
 	if opts != nil && opts.Lossless {
@@ -91,12 +246,7 @@ func Encode(w io.Writer, src image.Image, opts *EncodeOptions) error {
 		return err
 	}
 
-	quality := float32(75)
-	if opts != nil && opts.Quality > 0 {
-		quality = opts.Quality
-	}
-
-	enc, err := libwebp.WebPEncodeRGBA(nrgba.Pix, nrgba.Rect.Dx(), nrgba.Rect.Dy(), nrgba.Stride, quality)
+	enc, err := libwebp.WebPEncodeRGBA(nrgba.Pix, nrgba.Rect.Dx(), nrgba.Rect.Dy(), nrgba.Stride, opts.quality())
 	if err != nil {
 		return err
 	}
@@ -110,6 +260,335 @@ func EncodeLossless(w io.Writer, src image.Image) error {
 	return Encode(w, src, &EncodeOptions{Lossless: true})
 }
 
+// EstimateSize returns the size in bytes of the WebP file Encode would
+// produce for src with opts, without keeping the output. It always streams
+// through the advanced encoder, which produces the same bytes as Encode's
+// one-shot path; the output is counted as libwebp writes it and then
+// dropped, so only the encoder's working memory is allocated.
+func EstimateSize(src image.Image, opts *EncodeOptions) (int, error) {
+	if err := checkEncodeBounds(src.Bounds()); err != nil {
+		return 0, err
+	}
+	if err := opts.validate(); err != nil {
+		return 0, err
+	}
+	var w countingWriter
+	if err := encodeAdvanced(&w, src, opts, encodeHooks{}); err != nil {
+		return 0, err
+	}
+	return int(w), nil
+}
+
+// countingWriter discards what is written to it, counting the bytes.
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// EncodePaletted writes the paletted img, such as a decoded GIF frame, to w
+// as WebP. Nil opts encodes losslessly, which keeps the palette colors
+// exact and usually beats lossy on such images; non-nil opts are used as
+// given.
+func EncodePaletted(w io.Writer, img *image.Paletted, opts *EncodeOptions) error {
+	if opts == nil {
+		opts = &EncodeOptions{Lossless: true}
+	}
+	return Encode(w, img, opts)
+}
+
+func (o *EncodeOptions) quality() float32 {
+	if o != nil && o.Quality > 0 {
+		return o.Quality
+	}
+	return 75
+}
+
+// advanced reports whether o needs the WebPConfig/WebPPicture encode path
+// instead of the one-call shortcut encoders.
+func (o *EncodeOptions) advanced() bool {
+	return o != nil && (o.Exact || o.CleanupTransparent || o.AlphaFiltering != nil || o.AlphaCompression != nil ||
+		o.UseSharpYuv || o.NearLossless != nil || o.Method != nil ||
+		o.PartitionLimit != 0 || o.EmulateJpegSize || o.TargetSize != 0 ||
+		o.QMin != 0 || o.QMax != 0 || o.UseDeltaPalette)
+}
+
+// RequirementsMet reports whether the loaded libwebp honors every feature
+// that o enables. Older releases silently ignore unknown config fields, so
+// the unmet list names each such feature with the version it needs, letting
+// callers fail clearly before encoding. If libwebp cannot be loaded the
+// load error is the only entry.
+func (o *EncodeOptions) RequirementsMet() (bool, []string) {
+	_, encoder, err := libwebp.Version()
+	if err != nil {
+		return false, []string{err.Error()}
+	}
+
+	var unmet []string
+	for _, feature := range o.features() {
+		minVersion, _ := libwebp.FeatureMinVersion(feature)
+		if encoder < minVersion {
+			unmet = append(unmet, fmt.Sprintf("%s requires libwebp %s, have %s",
+				feature, libwebp.FormatVersion(minVersion), libwebp.FormatVersion(encoder)))
+		}
+	}
+	return len(unmet) == 0, unmet
+}
+
+// features lists the version-gated libwebp features o enables.
+func (o *EncodeOptions) features() []string {
+	if o == nil {
+		return nil
+	}
+	var features []string
+	if o.Exact {
+		features = append(features, libwebp.FeatureExact)
+	}
+	if o.UseSharpYuv {
+		features = append(features, libwebp.FeatureUseSharpYuv)
+	}
+	if o.NearLossless != nil {
+		features = append(features, libwebp.FeatureNearLossless)
+	}
+	if o.UseDeltaPalette {
+		features = append(features, libwebp.FeatureUseDeltaPalette)
+	}
+	if o.QMin != 0 {
+		features = append(features, libwebp.FeatureQMin)
+	}
+	if o.QMax != 0 {
+		features = append(features, libwebp.FeatureQMax)
+	}
+	return features
+}
+
+// validate checks the ranges of the advanced fields of o.
+func (o *EncodeOptions) validate() error {
+	if o == nil {
+		return nil
+	}
+	// Written to also catch NaN.
+	if !(o.Quality >= 0 && o.Quality <= 100) {
+		return fmt.Errorf("%w, got %v", ErrInvalidQuality, o.Quality)
+	}
+	if o.AlphaFiltering != nil && (*o.AlphaFiltering < 0 || *o.AlphaFiltering > 2) {
+		return fmt.Errorf("%w: AlphaFiltering %d out of range [0, 2]", ErrInvalidOption, *o.AlphaFiltering)
+	}
+	if o.AlphaCompression != nil && (*o.AlphaCompression < 0 || *o.AlphaCompression > 1) {
+		return fmt.Errorf("%w: AlphaCompression %d out of range [0, 1]", ErrInvalidOption, *o.AlphaCompression)
+	}
+	if o.NearLossless != nil && (*o.NearLossless < 0 || *o.NearLossless > 100) {
+		return fmt.Errorf("%w: NearLossless %d out of range [0, 100]", ErrInvalidOption, *o.NearLossless)
+	}
+	if o.Method != nil && (*o.Method < 0 || *o.Method > 6) {
+		return fmt.Errorf("%w: Method %d out of range [0, 6]", ErrInvalidOption, *o.Method)
+	}
+	if o.PartitionLimit < 0 || o.PartitionLimit > 100 {
+		return fmt.Errorf("%w: PartitionLimit %d out of range [0, 100]", ErrInvalidOption, o.PartitionLimit)
+	}
+	if o.TargetSize < 0 {
+		return fmt.Errorf("%w: negative TargetSize %d", ErrInvalidOption, o.TargetSize)
+	}
+	if o.QMin < 0 || o.QMin > 100 {
+		return fmt.Errorf("%w: QMin %d out of range [0, 100]", ErrInvalidOption, o.QMin)
+	}
+	if o.QMax < 0 || o.QMax > 100 {
+		return fmt.Errorf("%w: QMax %d out of range [0, 100]", ErrInvalidOption, o.QMax)
+	}
+	if o.QMax != 0 && o.QMin > o.QMax {
+		return fmt.Errorf("%w: QMin %d above QMax %d", ErrInvalidOption, o.QMin, o.QMax)
+	}
+	if o.UseDeltaPalette && !o.Lossless {
+		return fmt.Errorf("%w: UseDeltaPalette requires Lossless", ErrInvalidOption)
+	}
+	return nil
+}
+
+// config builds and validates the libwebp encoder config for o.
+func (o *EncodeOptions) config() (*libwebp.Config, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	config := new(libwebp.Config)
+	ok, err := libwebp.WebPConfigPreset(config, libwebp.PresetDefault, o.quality())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrEncodeFailed
+	}
+	if o != nil {
+		if o.Lossless {
+			config.Lossless = 1
+		}
+		if o.Exact {
+			config.Exact = 1
+		}
+		if o.AlphaFiltering != nil {
+			config.AlphaFiltering = int32(*o.AlphaFiltering)
+		}
+		if o.AlphaCompression != nil {
+			config.AlphaCompression = int32(*o.AlphaCompression)
+		}
+		if o.UseSharpYuv {
+			config.UseSharpYuv = 1
+		}
+		if o.NearLossless != nil {
+			config.NearLossless = int32(*o.NearLossless)
+		}
+		if o.Method != nil {
+			config.Method = int32(*o.Method)
+		}
+		config.PartitionLimit = int32(o.PartitionLimit)
+		if o.EmulateJpegSize {
+			config.EmulateJpegSize = 1
+		}
+		config.TargetSize = int32(o.TargetSize)
+		if o.UseDeltaPalette {
+			config.UseDeltaPalette = 1
+		}
+		if o.QMin != 0 {
+			config.QMin = int32(o.QMin)
+		}
+		if o.QMax != 0 {
+			config.QMax = int32(o.QMax)
+		}
+	}
+
+	ok, err = libwebp.WebPValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, libwebp.ErrEncodeFailed
+	}
+	return config, nil
+}
+
+// EncodeContext is like Encode but aborts encoding when ctx is done. The
+// encode runs on its own goroutine, which polls ctx from libwebp's progress
+// hook; once ctx is done EncodeContext waits for that goroutine to stop at
+// its next progress check and free its picture, so src is no longer read
+// when it returns. An aborted encode returns an error matching both
+// libwebp.ErrEncodeFailed and ctx.Err(), and writes nothing to w.
+func EncodeContext(ctx context.Context, w io.Writer, src image.Image, opts *EncodeOptions) error {
+	if err := checkEncodeBounds(src.Bounds()); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", libwebp.ErrEncodeFailed, err)
+	}
+
+	// Buffer the output so nothing reaches w when the encode is aborted.
+	var buf bytes.Buffer
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err = encodeAdvanced(&buf, src, opts, encodeHooks{
+			progress: func(int) bool { return ctx.Err() == nil },
+		})
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// The progress hook aborts the encode shortly; wait so that the
+		// caller may reuse src as soon as EncodeContext returns.
+		<-done
+		return fmt.Errorf("%w: %w", libwebp.ErrEncodeFailed, ctx.Err())
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("%w: %w", err, ctxErr)
+		}
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// EncodeWithStats is like Encode but always uses the advanced encoder and
+// returns the statistics libwebp collected: coded size, PSNR, block counts
+// and layer sizes.
+func EncodeWithStats(w io.Writer, src image.Image, opts *EncodeOptions) (*libwebp.AuxStats, error) {
+	if err := checkEncodeBounds(src.Bounds()); err != nil {
+		return nil, err
+	}
+
+	stats := new(libwebp.AuxStats)
+	if err := encodeAdvanced(w, src, opts, encodeHooks{stats: stats}); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// encodeHooks carries optional per-encode attachments for encodeAdvanced.
+type encodeHooks struct {
+	// progress is installed as the picture progress hook.
+	progress func(percent int) bool
+	// stats receives the post-encode statistics.
+	stats *libwebp.AuxStats
+}
+
+// encodeAdvanced encodes src through WebPEncode with the full config built
+// from opts, streaming the output into w as libwebp produces it.
+func encodeAdvanced(w io.Writer, src image.Image, opts *EncodeOptions, hooks encodeHooks) error {
+	config, err := opts.config()
+	if err != nil {
+		return err
+	}
+
+	return withSourcePicture(src, opts, func(pic *libwebp.Picture) error {
+		if hooks.progress != nil {
+			libwebp.SetPictureProgressHook(pic, hooks.progress)
+			defer libwebp.SetPictureProgressHook(pic, nil)
+		}
+		if hooks.stats != nil {
+			release := libwebp.AttachPictureStats(pic, hooks.stats)
+			defer release()
+		}
+		if err := opts.preparePicture(pic); err != nil {
+			return err
+		}
+		return libwebp.WebPEncodeToWriter(config, pic, w)
+	})
+}
+
+// withSourcePicture imports src into a libwebp picture for encoding with
+// opts: YUV straight from the planes of a YCbCr image, ARGB otherwise.
+func withSourcePicture(src image.Image, opts *EncodeOptions, fn func(pic *libwebp.Picture) error) error {
+	if ycbcr, ok := encodesYCbCrDirectly(src, opts); ok {
+		return withYCbCrPicture(ycbcr, fn)
+	}
+	return withPicture(toNRGBA(src), fn)
+}
+
+// preparePicture applies the pixel preprocessing requested by o to pic
+// before it is encoded.
+func (o *EncodeOptions) preparePicture(pic *libwebp.Picture) error {
+	if o != nil && o.CleanupTransparent && !o.Exact {
+		return libwebp.WebPCleanupTransparentArea(pic)
+	}
+	return nil
+}
+
+// checkEncodeBounds rejects images that libwebp cannot encode or that exceed
+// the limit configured with SetMaxEncodePixels.
+func checkEncodeBounds(b image.Rectangle) error {
+	width, height := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 {
+		return libwebp.ErrInvalidDimension
+	}
+	if width > MaxDimension || height > MaxDimension {
+		return ErrImageTooLarge
+	}
+	if limit := maxEncodePixels.Load(); limit > 0 && int64(width)*int64(height) > limit {
+		return ErrImageTooLarge
+	}
+	return nil
+}
+
 // decodeNRGBALayout verifies the Go allocation and C int stride constraints
 // without allocating the output buffer.
 func decodeNRGBALayout(width, height int) (stride, size int, err error) {
@@ -126,11 +605,26 @@ func decodeNRGBALayout(width, height int) (stride, size int, err error) {
 	return stride, stride * height, nil
 }
 
+// toNRGBA returns src as an *image.NRGBA, converting it when needed. The
+// common concrete types are converted in bulk; see convert.go.
 func toNRGBA(src image.Image) *image.NRGBA {
-	if nrgba, ok := src.(*image.NRGBA); ok {
-		return nrgba
+	switch src := src.(type) {
+	case *image.NRGBA:
+		return src
+	case *image.RGBA:
+		return rgbaToNRGBA(src)
+	case *image.Gray:
+		return grayToNRGBA(src)
+	case *image.YCbCr:
+		return ycbcrToNRGBA(src)
+	case *image.Paletted:
+		return palettedToNRGBA(src)
 	}
+	return convertNRGBA(src)
+}
 
+// convertNRGBA converts any image pixel by pixel through color.NRGBAModel.
+func convertNRGBA(src image.Image) *image.NRGBA {
 	b := src.Bounds()
 	nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
 	for y := b.Min.Y; y < b.Max.Y; y++ {
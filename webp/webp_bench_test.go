@@ -5,6 +5,8 @@ import (
 	"image"
 	"image/color"
 	"testing"
+
+	"github.com/bnema/purego-webp/libwebp"
 )
 
 var benchmarkDecodedImage image.Image
@@ -17,7 +19,9 @@ func BenchmarkDecodeLarge1024(b *testing.B) {
 	benchmarkDecodeNRGBA(b, 1024, 1024)
 }
 
-func benchmarkDecodeNRGBA(b *testing.B, width, height int) {
+// benchmarkFixture returns a lossless WebP of a width x height gradient with
+// varying alpha.
+func benchmarkFixture(b *testing.B, width, height int) []byte {
 	b.Helper()
 
 	source := image.NewNRGBA(image.Rect(0, 0, width, height))
@@ -36,7 +40,12 @@ func benchmarkDecodeNRGBA(b *testing.B, width, height int) {
 	if err := EncodeLossless(&encoded, source); err != nil {
 		b.Fatalf("encode benchmark fixture: %v", err)
 	}
-	data := encoded.Bytes()
+	return encoded.Bytes()
+}
+
+func benchmarkDecodeNRGBA(b *testing.B, width, height int) {
+	b.Helper()
+	data := benchmarkFixture(b, width, height)
 
 	b.ReportAllocs()
 	b.SetBytes(int64(len(data)))
@@ -49,3 +58,110 @@ func benchmarkDecodeNRGBA(b *testing.B, width, height int) {
 		benchmarkDecodedImage = decoded
 	}
 }
+
+func BenchmarkDecoderReuseFavicon64(b *testing.B) {
+	benchmarkDecoderNRGBA(b, 64, 64)
+}
+
+func BenchmarkDecoderReuseLarge1024(b *testing.B) {
+	benchmarkDecoderNRGBA(b, 1024, 1024)
+}
+
+// benchmarkDecoderNRGBA mirrors benchmarkDecodeNRGBA with a reused Decoder,
+// which allocates nothing per image after the first decode.
+func benchmarkDecoderNRGBA(b *testing.B, width, height int) {
+	b.Helper()
+	data := benchmarkFixture(b, width, height)
+
+	var d Decoder
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for range b.N {
+		decoded, err := d.Decode(data)
+		if err != nil {
+			b.Fatalf("decode benchmark fixture: %v", err)
+		}
+		benchmarkDecodedImage = decoded
+	}
+}
+
+func BenchmarkDecodeViaOwnedBuffer1024(b *testing.B) {
+	benchmarkDecodeViaOwnedBuffer(b, 1024, 1024)
+}
+
+// benchmarkDecodeViaOwnedBuffer is the baseline for benchmarkDecodeNRGBA:
+// decoding into a libwebp-owned buffer and copying it into an NRGBA costs
+// an extra full-size buffer per image that Decode avoids.
+func benchmarkDecodeViaOwnedBuffer(b *testing.B, width, height int) {
+	b.Helper()
+	data := benchmarkFixture(b, width, height)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for range b.N {
+		pix, w, h, stride, err := libwebp.WebPDecodeRGBA(data)
+		if err != nil {
+			b.Fatalf("decode benchmark fixture: %v", err)
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := range h {
+			copy(img.Pix[y*img.Stride:y*img.Stride+w*4], pix[y*stride:])
+		}
+		benchmarkDecodedImage = img
+	}
+}
+
+// lossyBenchmarkFixture returns a lossy encode of a width x height
+// gradient with some texture, for the decode option benchmarks.
+func lossyBenchmarkFixture(b *testing.B, width, height int) []byte {
+	b.Helper()
+	source := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			source.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := Encode(&encoded, source, &EncodeOptions{Quality: 75}); err != nil {
+		b.Fatalf("encode benchmark fixture: %v", err)
+	}
+	return encoded.Bytes()
+}
+
+func benchmarkDecodeWithOptions(b *testing.B, data []byte, opts *DecodeOptions) {
+	b.Helper()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for range b.N {
+		decoded, err := decodeWithOptions(data, opts)
+		if err != nil {
+			b.Fatalf("decode benchmark fixture: %v", err)
+		}
+		benchmarkDecodedImage = decoded
+	}
+}
+
+func BenchmarkDecodeLossy1024(b *testing.B) {
+	data := lossyBenchmarkFixture(b, 1024, 1024)
+	b.Run("default", func(b *testing.B) { benchmarkDecodeWithOptions(b, data, nil) })
+	b.Run("NoFancyUpsampling", func(b *testing.B) {
+		benchmarkDecodeWithOptions(b, data, &DecodeOptions{NoFancyUpsampling: true})
+	})
+	b.Run("BypassFiltering", func(b *testing.B) {
+		benchmarkDecodeWithOptions(b, data, &DecodeOptions{BypassFiltering: true})
+	})
+	b.Run("both", func(b *testing.B) {
+		benchmarkDecodeWithOptions(b, data, &DecodeOptions{NoFancyUpsampling: true, BypassFiltering: true})
+	})
+}
+
+func BenchmarkDecodeLossy4K(b *testing.B) {
+	data := lossyBenchmarkFixture(b, 3840, 2160)
+	b.Run("single", func(b *testing.B) { benchmarkDecodeWithOptions(b, data, nil) })
+	b.Run("UseThreads", func(b *testing.B) {
+		benchmarkDecodeWithOptions(b, data, &DecodeOptions{UseThreads: true})
+	})
+}
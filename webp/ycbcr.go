@@ -0,0 +1,175 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"io"
+
+	"github.com/bnema/purego-webp/libwebp"
+)
+
+// Go's image.YCbCr (as decoded from JPEG) holds full-range JFIF samples,
+// while libwebp encodes BT.601 limited-range YUV: luma in [16, 235] and
+// chroma in [16, 240]. These tables map one to the other and back.
+var limitedLuma, limitedChroma, fullLuma, fullChroma [256]uint8
+
+func init() {
+	for i := range 256 {
+		limitedLuma[i] = uint8(16 + (i*219+127)/255)
+		limitedChroma[i] = uint8(128 + roundDiv((i-128)*224, 255))
+		fullLuma[i] = uint8(min(max(roundDiv((i-16)*255, 219), 0), 255))
+		fullChroma[i] = uint8(min(max(128+roundDiv((i-128)*255, 224), 0), 255))
+	}
+}
+
+// roundDiv returns n/d rounded half away from zero, for d > 0, so that the
+// range mappings are symmetric around their midpoint.
+func roundDiv(n, d int) int {
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
+
+// DecodeYCbCr reads a lossy or lossless WebP image from r and returns its
+// 4:2:0 Y, Cb and Cr planes, without the conversion to RGB, for pipelines
+// that re-encode to JPEG or video. The samples are expanded from libwebp's
+// limited range to the full range image.YCbCr uses. Alpha, if any, is
+// dropped.
+func DecodeYCbCr(r io.Reader) (*image.YCbCr, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	y, u, v, w, h, yStride, uvStride, err := libwebp.WebPDecodeYUV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	uvW, uvH := (w+1)/2, (h+1)/2
+	for row := range h {
+		expandRange(y[row*yStride:][:w], &fullLuma)
+	}
+	for row := range uvH {
+		expandRange(u[row*uvStride:][:uvW], &fullChroma)
+		expandRange(v[row*uvStride:][:uvW], &fullChroma)
+	}
+	img := libwebp.YUVToYCbCr(y, u, v, w, h, yStride, uvStride)
+	if img == nil {
+		return nil, libwebp.ErrDecodeFailed
+	}
+	return img, nil
+}
+
+func expandRange(samples []byte, table *[256]uint8) {
+	for i, s := range samples {
+		samples[i] = table[s]
+	}
+}
+
+// EncodeGray writes the grayscale img to w as WebP. Lossy encodes take the
+// gray samples as the luma plane, with flat chroma, instead of expanding
+// img to RGBA first; lossless encodes, which work on RGB, replicate the
+// samples into opaque RGB.
+func EncodeGray(w io.Writer, img *image.Gray, opts *EncodeOptions) error {
+	if opts != nil && opts.Lossless {
+		return Encode(w, img, opts)
+	}
+	return Encode(w, grayYCbCr(img), opts)
+}
+
+// grayYCbCr returns a 4:2:0 image.YCbCr whose luma plane aliases img and
+// whose chroma is neutral, so that it has the colors of img.
+func grayYCbCr(img *image.Gray) *image.YCbCr {
+	r := img.Rect
+	cw := (r.Max.X+1)/2 - r.Min.X/2
+	ch := (r.Max.Y+1)/2 - r.Min.Y/2
+	chroma := bytes.Repeat([]byte{128}, cw*ch)
+	return &image.YCbCr{
+		Y:              img.Pix,
+		Cb:             chroma,
+		Cr:             chroma,
+		YStride:        img.Stride,
+		CStride:        cw,
+		SubsampleRatio: image.YCbCrSubsampleRatio420,
+		Rect:           r,
+	}
+}
+
+// encodesYCbCrDirectly reports whether src is encoded from its Y, Cb and Cr
+// planes instead of being converted to RGB first. Lossless encoding works
+// on RGB, so it always takes the NRGBA path.
+func encodesYCbCrDirectly(src image.Image, opts *EncodeOptions) (*image.YCbCr, bool) {
+	ycbcr, ok := src.(*image.YCbCr)
+	if !ok || (opts != nil && opts.Lossless) {
+		return nil, false
+	}
+	switch ycbcr.SubsampleRatio {
+	case image.YCbCrSubsampleRatio420, image.YCbCrSubsampleRatio422, image.YCbCrSubsampleRatio444:
+		return ycbcr, true
+	}
+	return nil, false
+}
+
+// withYCbCrPicture imports img into a 4:2:0 YUV libwebp picture, calls fn
+// and frees the picture afterwards. Chroma is averaged down to 4:2:0 when
+// img is less subsampled.
+func withYCbCrPicture(img *image.YCbCr, fn func(pic *libwebp.Picture) error) error {
+	var pic libwebp.Picture
+	ok, err := libwebp.WebPPictureInit(&pic)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return libwebp.ErrEncodeFailed
+	}
+	b := img.Rect
+	w, h := b.Dx(), b.Dy()
+	pic.Width = int32(w)
+	pic.Height = int32(h)
+	defer libwebp.WebPPictureFree(&pic)
+
+	y := make([]byte, w*h)
+	for row := range h {
+		src := img.Y[img.YOffset(b.Min.X, b.Min.Y+row):][:w:w]
+		dst := y[row*w:][:w:w]
+		for x, v := range src {
+			dst[x] = limitedLuma[v]
+		}
+	}
+
+	// Each 4:2:0 chroma sample covers a 2x2 luma block; average the source
+	// chroma over the block (clipped to the image), which is an exact copy
+	// when img is already 4:2:0 and aligned.
+	uvW, uvH := (w+1)/2, (h+1)/2
+	u := make([]byte, uvW*uvH)
+	v := make([]byte, uvW*uvH)
+	for cy := range uvH {
+		for cx := range uvW {
+			var cb, cr, n int
+			for dy := range 2 {
+				for dx := range 2 {
+					px, py := 2*cx+dx, 2*cy+dy
+					if px >= w || py >= h {
+						continue
+					}
+					off := img.COffset(b.Min.X+px, b.Min.Y+py)
+					cb += int(img.Cb[off])
+					cr += int(img.Cr[off])
+					n++
+				}
+			}
+			u[cy*uvW+cx] = limitedChroma[(cb+n/2)/n]
+			v[cy*uvW+cx] = limitedChroma[(cr+n/2)/n]
+		}
+	}
+
+	ok, err = libwebp.WebPPictureImportYUV420(&pic, y, u, v, w, uvW)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return libwebp.ErrEncodeFailed
+	}
+	return fn(&pic)
+}